@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// AuditRecord is one JSON line of the audit log. A run writes a "start"
+// record, one "delete" record per item and an "end" record.
+type AuditRecord struct {
+	Time    time.Time  `json:"time"`
+	RunID   string     `json:"run_id"`
+	Event   string     `json:"event"`
+	User    string     `json:"user,omitempty"`
+	Host    string     `json:"host,omitempty"`
+	Cwd     string     `json:"cwd,omitempty"`
+	Command []string   `json:"command,omitempty"`
+	Path    string     `json:"path,omitempty"`
+	IsDir   bool       `json:"is_dir,omitempty"`
+	Size    int64      `json:"size,omitempty"`
+	ModTime *time.Time `json:"mtime,omitempty"`
+	SHA256  string     `json:"sha256,omitempty"`
+	Outcome string     `json:"outcome,omitempty"`
+	Method  string     `json:"method,omitempty"` // how a deleted item went: trashed, moved, ...; empty when removed for good
+	Error   string     `json:"error,omitempty"`
+	Deleted int        `json:"deleted,omitempty"`
+	Failed  int        `json:"failed,omitempty"`
+	Skipped int        `json:"skipped,omitempty"`
+	Left    int        `json:"left,omitempty"`
+}
+
+// auditLog appends records for a single run
+type auditLog struct {
+	file   *os.File
+	runID  string
+	hash   bool
+	syslog io.Writer
+}
+
+// defaultAuditPath returns $XDG_STATE_HOME/delf/audit.log
+func defaultAuditPath() string {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "delf", "audit.log")
+}
+
+// auditPath returns the audit log location from --audit-log or the default
+func auditPath() string {
+	if opts.AuditLog != "" {
+		return opts.AuditLog
+	}
+	return defaultAuditPath()
+}
+
+// newRunID returns a sortable, unique identifier for this invocation
+func newRunID() string {
+	buf := make([]byte, 3)
+	rand.Read(buf)
+	return time.Now().Format("20060102T150405") + "-" + hex.EncodeToString(buf)
+}
+
+// openAuditLog opens the audit log for appending and writes the start record
+func openAuditLog(path string, hash, forwardSyslog bool) (*auditLog, error) {
+	if path == "" {
+		return nil, fmt.Errorf("cannot determine audit log location")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := &auditLog{file: file, runID: newRunID(), hash: hash}
+	if forwardSyslog {
+		if audit.syslog, err = openSyslog(); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	start := AuditRecord{Event: "start", User: currentUser(), Command: os.Args}
+	start.Host, _ = os.Hostname()
+	start.Cwd, _ = os.Getwd()
+	audit.write(start)
+
+	return audit, nil
+}
+
+// currentUser returns the user name, noting the invoking user under sudo
+func currentUser() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && sudoUser != name {
+		name += " (sudo from " + sudoUser + ")"
+	}
+	return name
+}
+
+// write stamps and appends a record; audit failures never stop a deletion
+func (a *auditLog) write(record AuditRecord) {
+	record.Time = time.Now()
+	record.RunID = a.runID
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	a.file.Write(append(line, '\n'))
+	if a.syslog != nil {
+		a.syslog.Write(line)
+	}
+}
+
+// describe captures size, mtime and optionally the hash of a result before it is removed
+func (a *auditLog) describe(result delf.Result) AuditRecord {
+	record := AuditRecord{Event: "delete", Path: result.Path, IsDir: result.IsDir}
+	if a == nil {
+		return record
+	}
+
+	info, err := os.Lstat(result.Path)
+	if err != nil {
+		return record
+	}
+	modTime := info.ModTime()
+	record.ModTime = &modTime
+
+	if info.IsDir() {
+		record.Size = delf.TotalSize([]delf.Result{result})
+		return record
+	}
+
+	record.Size = info.Size()
+	if a.hash && info.Mode().IsRegular() {
+		record.SHA256, _ = delf.HashFile(result.Path)
+	}
+	return record
+}
+
+// item records the outcome for a single result
+func (a *auditLog) item(record AuditRecord, outcome string, err error) {
+	if a == nil {
+		return
+	}
+	record.Outcome = outcome
+	if err != nil {
+		record.Error = err.Error()
+	}
+	a.write(record)
+}
+
+// close writes the end record with the run totals; left counts items never
+// attempted because the run was interrupted (unknown, and 0, for streaming runs)
+func (a *auditLog) close(deleted, failed, skipped, left int, interrupted bool) {
+	if a == nil {
+		return
+	}
+	record := AuditRecord{Event: "end", Deleted: deleted, Failed: failed, Skipped: skipped, Left: left}
+	if interrupted {
+		record.Outcome = "interrupted"
+	}
+	a.write(record)
+	a.file.Close()
+	if closer, ok := a.syslog.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// runLogCommand implements `delf log`, which queries the audit log
+func runLogCommand(args []string) {
+	fs := flag.NewFlagSet("log", flag.ExitOnError)
+	file := fs.String("file", defaultAuditPath(), "Audit log to read")
+	since := fs.String("since", "", "Only show runs on or after DATE")
+	until := fs.String("until", "", "Only show runs before DATE")
+	prefix := fs.String("path", "", "Only show items under PATH")
+	runID := fs.String("run", "", "Only show the run with this ID")
+	raw := fs.Bool("json", false, "Print matching records as JSON lines")
+	fs.Usage = showLogHelp
+	fs.Parse(args)
+
+	var sinceTime, untilTime time.Time
+	var err error
+	if *since != "" {
+		if sinceTime, err = delf.ParseDate(*since); err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			os.Exit(1)
+		}
+	}
+	if *until != "" {
+		if untilTime, err = delf.ParseDate(*until); err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			os.Exit(1)
+		}
+	}
+	if *prefix != "" {
+		if abs, err := filepath.Abs(*prefix); err == nil {
+			*prefix = abs
+		}
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	// Runs are printed lazily so a header only appears when one of its items matches
+	var pending *AuditRecord
+	shownRun := ""
+	matched := 0
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+
+		if *runID != "" && !strings.HasPrefix(record.RunID, *runID) {
+			continue
+		}
+		if !sinceTime.IsZero() && record.Time.Before(sinceTime) {
+			continue
+		}
+		if !untilTime.IsZero() && !record.Time.Before(untilTime) {
+			continue
+		}
+
+		if record.Event == "start" {
+			start := record
+			pending = &start
+			continue
+		}
+		if record.Event != "delete" {
+			continue
+		}
+		if *prefix != "" && record.Path != *prefix && !strings.HasPrefix(record.Path, *prefix+string(filepath.Separator)) {
+			continue
+		}
+
+		matched++
+		if *raw {
+			fmt.Fprintln(console, scanner.Text())
+			continue
+		}
+		if record.RunID != shownRun {
+			if pending != nil && pending.RunID == record.RunID {
+				showAuditRun(*pending)
+			} else {
+				showAuditRun(AuditRecord{RunID: record.RunID, Time: record.Time})
+			}
+			shownRun = record.RunID
+		}
+		showAuditItem(record)
+	}
+
+	if matched == 0 && !*raw {
+		fmt.Fprintln(console, colors.Yellow("No matching deletions in the audit log"))
+	}
+}
+
+// showAuditRun displays the header of one run in `delf log`
+func showAuditRun(start AuditRecord) {
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "%s %s %s\n",
+		colors.Bold("Run"),
+		colors.Cyan(start.RunID),
+		colors.Dim(start.Time.Local().Format("2006-01-02 15:04:05")))
+	if start.User != "" || start.Host != "" {
+		fmt.Fprintf(console, "  %s %s@%s  %s %s\n", colors.Blue("By:"), start.User, start.Host, colors.Blue("In:"), start.Cwd)
+	}
+	if len(start.Command) > 0 {
+		fmt.Fprintf(console, "  %s %s\n", colors.Blue("Command:"), strings.Join(start.Command, " "))
+	}
+}
+
+// showAuditItem displays one deleted item in `delf log`
+func showAuditItem(record AuditRecord) {
+	detail := formatSize(record.Size)
+	if record.SHA256 != "" {
+		detail += " sha256:" + record.SHA256[:12]
+	}
+
+	switch record.Outcome {
+	case "deleted":
+		if record.Method != "" {
+			detail += ", " + record.Method
+		}
+		fmt.Fprintf(console, "  %s %s %s\n", colors.Green("OK"), record.Path, colors.Dim("("+detail+")"))
+	case "skipped":
+		fmt.Fprintf(console, "  %s %s %s\n", colors.Yellow("! "), record.Path, colors.Yellow("(skipped)"))
+	default:
+		fmt.Fprintf(console, "  %s %s %s\n", colors.Red("X "), record.Path, colors.Yellow("("+record.Error+")"))
+	}
+}
+
+// showLogHelp displays usage for `delf log`
+func showLogHelp() {
+	fmt.Fprintln(console, colors.Bold("USAGE:"))
+	fmt.Fprintln(console, "    delf log [OPTIONS]")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("OPTIONS:"))
+	fmt.Fprintf(console, "    %s      Only show runs on or after DATE (YYYY-MM-DD or RFC 3339)\n", colors.Cyan("--since DATE"))
+	fmt.Fprintf(console, "    %s      Only show runs before DATE\n", colors.Cyan("--until DATE"))
+	fmt.Fprintf(console, "    %s       Only show items under PATH\n", colors.Cyan("--path PATH"))
+	fmt.Fprintf(console, "    %s         Only show the run with this ID (prefix match)\n", colors.Cyan("--run ID"))
+	fmt.Fprintf(console, "    %s       Read a different audit log (default: %s)\n", colors.Cyan("--file FILE"), defaultAuditPath())
+	fmt.Fprintf(console, "    %s            Print matching records as JSON lines\n", colors.Cyan("--json"))
+}
@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// openSyslog forwards audit records to the local syslog daemon
+// (journald picks these up on systemd hosts)
+func openSyslog() (io.Writer, error) {
+	return syslog.New(syslog.LOG_NOTICE|syslog.LOG_AUTH, "delf")
+}
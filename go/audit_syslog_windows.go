@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// openSyslog is not available on Windows
+func openSyslog() (io.Writer, error) {
+	return nil, fmt.Errorf("syslog forwarding is not supported on Windows")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+func TestAuditLogMethods(t *testing.T) {
+	root := t.TempDir()
+	quarantine := t.TempDir()
+	tests := []struct {
+		name    string
+		deleter delf.Deleter
+		method  string
+	}{
+		{"permanent", delf.PermanentDeleter{}, ""},
+		{"move", &delf.MoveDeleter{Dest: quarantine, Root: root}, "moved"},
+		{"archive", &delf.ArchiveDeleter{Path: filepath.Join(t.TempDir(), "out.tar"), Root: root}, "archived and deleted"},
+		{"shred", delf.ShredDeleter{Passes: 1}, "shredded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := recordRun(t)
+			path := filepath.Join(root, tt.name+".log")
+			touchFile(t, path)
+
+			logFile := filepath.Join(t.TempDir(), "audit.log")
+			audit, err := openAuditLog(logFile, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			deleted, failed, _, _ := performDeletion(context.Background(), []delf.Result{{Path: path}}, tt.deleter, audit)
+			audit.close(deleted, failed, 0, 0, false)
+			if deleted != 1 {
+				t.Fatalf("performDeletion deleted %d: %s", deleted, out)
+			}
+
+			out.Reset()
+			runLogCommand([]string{"--file", logFile})
+			shown := out.String()
+			if !strings.Contains(shown, "OK "+path) || strings.Contains(shown, "X ") {
+				t.Fatalf("delf log does not show %s as done:\n%s", path, shown)
+			}
+			if tt.method != "" && !strings.Contains(shown, tt.method+")") {
+				t.Fatalf("delf log does not name the method %q:\n%s", tt.method, shown)
+			}
+
+			out.Reset()
+			runLogCommand([]string{"--file", logFile, "--json"})
+			want := `"outcome":"deleted"`
+			if tt.method != "" {
+				want += `,"method":"` + tt.method + `"`
+			}
+			if !strings.Contains(out.String(), want) {
+				t.Fatalf("audit record %s lacks %s", out, want)
+			}
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
 )
 
 // DeleteResult holds the result of a deletion attempt
@@ -12,75 +17,192 @@ type DeleteResult struct {
 	Error   error
 }
 
-// deleteFile deletes a single file or directory
-func deleteFile(path string) error {
-	return os.RemoveAll(path)
-}
+// performDeletion hands every result to the deleter, recording each outcome in the audit log
+// and reporting it to the sink. Files open by another process are skipped with --skip-busy.
+// Once ctx is cancelled no further item is started; those items are returned as left.
+func performDeletion(ctx context.Context, results []delf.Result, deleter delf.Deleter, audit *auditLog) (deleted, failed, skipped int, left []delf.Result) {
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold(colors.Red(deleter.Heading())))
+	fmt.Fprintln(console)
 
-// performDeletion deletes all files in the results list
-func performDeletion(results []SearchResult, verbose bool) (deleted, failed int) {
-	fmt.Println()
-	fmt.Println(colors.Bold(colors.Red("Deleting...")))
-	fmt.Println()
+	// Some deleters need the whole batch first (e.g. to write an archive)
+	if p, ok := deleter.(delf.Preparer); ok {
+		prepared, err := p.Prepare(results)
+		if err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.BoldRed("X Aborted:"), err)
+			return 0, len(results), 0, nil
+		}
+		results = prepared
+	}
+	if a, ok := deleter.(*delf.ArchiveDeleter); ok {
+		for _, result := range a.Unreadable() {
+			sink.Event(delf.Event{Kind: delf.EventSkipped, Path: result.Path, Result: result, Reason: "unreadable, kept and not archived"})
+		}
+		fmt.Fprintf(console, "%s %d entries in %s\n", colors.Green("OK Verified:"), a.Entries(), a.Path)
+		fmt.Fprintln(console)
+	}
+	defer finishDeleter(deleter)
 
-	for _, result := range results {
-		// Check if file still exists
-		if _, err := os.Stat(result.Path); os.IsNotExist(err) {
-			continue
+	for i, result := range results {
+		// Stop between items when interrupted
+		if ctx.Err() != nil {
+			return deleted, failed, skipped, results[i:]
 		}
 
-		err := deleteFile(result.Path)
-		if err != nil {
-			failed++
-			if verbose {
-				fmt.Printf("%s Failed: %s %s\n",
-					colors.Red("X"),
-					result.Path,
-					colors.Yellow(fmt.Sprintf("(%s)", err.Error())))
-			}
-		} else {
+		switch disposeOf(result, deleter, audit) {
+		case itemDeleted:
 			deleted++
-			if verbose {
-				fmt.Printf("%s Deleted: %s\n",
-					colors.Green("OK"),
-					colors.Red(result.Path))
-			}
+		case itemFailed:
+			failed++
+		case itemSkipped:
+			skipped++
 		}
 	}
 
-	return deleted, failed
+	return deleted, failed, skipped, nil
+}
+
+// finishDeleter closes the deleter, telling the user how to undo moves
+func finishDeleter(deleter delf.Deleter) {
+	if err := delf.CloseDeleter(deleter); err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Yellow("Warning:"), err)
+		return
+	}
+	if m, ok := deleter.(*delf.MoveDeleter); ok && m.ManifestPath() != "" {
+		fmt.Fprintln(console)
+		fmt.Fprintf(console, "%s %s\n", colors.Blue("Manifest:"), colors.Cyan(m.ManifestPath()))
+		fmt.Fprintf(console, "%s delf restore %s\n", colors.Blue("Undo with:"), m.ManifestPath())
+	}
+}
+
+// itemOutcome is what happened to one result
+type itemOutcome int
+
+const (
+	itemGone    itemOutcome = iota // removed by someone else in the meantime
+	itemDeleted                    // disposed of by the deleter
+	itemFailed
+	itemSkipped // busy with --skip-busy, or left in place by the deleter
+)
+
+// skipError is returned by a deleter that decided to leave an item in place
+type skipError struct{ reason string }
+
+func (e skipError) Error() string { return e.reason }
+
+// disposeOf hands one result to the deleter, recording the outcome in the audit log
+// and reporting it to the sink
+func disposeOf(result delf.Result, deleter delf.Deleter, audit *auditLog) itemOutcome {
+	// Check if the path still exists; Lstat so a dangling symlink is still removed
+	if _, err := os.Lstat(result.Path); os.IsNotExist(err) {
+		return itemGone
+	}
+
+	record := audit.describe(result)
+
+	// Check if a process still holds it open
+	if len(result.Holders) > 0 && opts.SkipBusy {
+		audit.item(record, "skipped", nil)
+		sink.Event(delf.Event{Kind: delf.EventSkipped, Path: result.Path, Result: result,
+			Reason: "in use by " + delf.FormatHolders(result.Holders)})
+		return itemSkipped
+	}
+
+	if err := deleter.Delete(result); err != nil {
+		var skip skipError
+		if errors.As(err, &skip) {
+			audit.item(record, "skipped", nil)
+			sink.Event(delf.Event{Kind: delf.EventSkipped, Path: result.Path, Result: result, Reason: skip.reason})
+			return itemSkipped
+		}
+		audit.item(record, "failed", err)
+		sink.Event(delf.Event{Kind: delf.EventFailed, Path: result.Path, Result: result, Err: err})
+		return itemFailed
+	}
+	if method := strings.ToLower(deleter.Verb()); method != "deleted" {
+		record.Method = method
+	}
+	audit.item(record, "deleted", nil)
+	sink.Event(delf.Event{Kind: delf.EventDeleted, Path: result.Path, Result: result, Verb: deleter.Verb()})
+	return itemDeleted
 }
 
 // previewDeletion shows what would be deleted without actually deleting
-func previewDeletion(results []SearchResult, maxPreview int) {
-	fmt.Println()
-	fmt.Println(colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
-	fmt.Printf("%s %d items:\n", colors.BoldRed("Will delete"), len(results))
-	fmt.Println()
+func previewDeletion(results []delf.Result, maxPreview int) {
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintf(console, "%s %d items:\n", colors.BoldRed("Will delete"), len(results))
+	fmt.Fprintln(console)
 
 	count := 0
 	for _, result := range results {
 		if count >= maxPreview {
 			remaining := len(results) - maxPreview
 			if remaining > 0 {
-				fmt.Printf("%s\n", colors.Yellow(fmt.Sprintf("  ... and %d more", remaining)))
+				fmt.Fprintf(console, "%s\n", colors.Yellow(fmt.Sprintf("  ... and %d more", remaining)))
 			}
 			break
 		}
 		showMatchResult(result.Path, result.IsDir)
+		if result.Nested > 0 {
+			fmt.Fprintf(console, "%s\n", colors.Dim(fmt.Sprintf("      includes %d nested matches", result.Nested)))
+		}
+		if len(result.Holders) > 0 {
+			fmt.Fprintf(console, "%s\n", colors.Yellow(fmt.Sprintf("      in use by %s", delf.FormatHolders(result.Holders))))
+		}
 		count++
 	}
+
+	if busy := delf.CountBusy(results); busy > 0 {
+		fmt.Fprintln(console)
+		if opts.SkipBusy {
+			fmt.Fprintf(console, "%s %d items are open by running processes and will be skipped\n", colors.Yellow("Note:"), busy)
+		} else {
+			fmt.Fprintf(console, "%s %d items are open by running processes (use %s to leave them)\n",
+				colors.Yellow("Warning:"), busy, colors.Cyan("--skip-busy"))
+		}
+	}
 }
 
 // showExcludedFiles displays files that were excluded
-func showExcludedFiles(excluded []SearchResult) {
+func showExcludedFiles(excluded []delf.Result) {
 	if len(excluded) == 0 {
 		return
 	}
 
-	fmt.Println()
-	fmt.Printf("%s (%d items):\n", colors.Green(colors.Bold("Excluded")), len(excluded))
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "%s (%d items):\n", colors.Green(colors.Bold("Excluded")), len(excluded))
 	for _, result := range excluded {
-		fmt.Printf("%s %s\n", colors.Green("  OK"), result.Path)
+		fmt.Fprintf(console, "%s %s\n", colors.Green("  OK"), result.Path)
+	}
+}
+
+// showRetainedFiles displays matches kept back by the retention rules
+func showRetainedFiles(kept []delf.Result) {
+	if len(kept) == 0 {
+		return
+	}
+
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "%s %s (%d items):\n", colors.Green(colors.Bold("Kept")), colors.Dim("("+retention.String()+")"), len(kept))
+	for i, result := range kept {
+		if i == 10 {
+			fmt.Fprintf(console, "%s\n", colors.Green(fmt.Sprintf("  ... and %d more", len(kept)-10)))
+			break
+		}
+		fmt.Fprintf(console, "%s %s\n", colors.Green("  OK"), result.Path)
+	}
+}
+
+// showGitProtectedFiles displays files kept back by --git-safe
+func showGitProtectedFiles(protected []delf.Result) {
+	if len(protected) == 0 {
+		return
+	}
+
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "%s (%d items):\n", colors.Yellow("Protected by git"), len(protected))
+	for _, result := range protected {
+		fmt.Fprintf(console, "%s %s %s\n", colors.Yellow("  ! "), result.Path, colors.Dim(fmt.Sprintf("(%s)", result.Git)))
 	}
 }
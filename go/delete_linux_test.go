@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+func TestSkipBusyHeldOpen(t *testing.T) {
+	_, recorder := recordRun(t)
+	opts.SkipBusy = true
+
+	root := t.TempDir()
+	held := filepath.Join(root, "held.log")
+	closed := filepath.Join(root, "closed.log")
+	touchFile(t, held)
+	touchFile(t, closed)
+
+	// Another process keeps held.log open on its stdin
+	f, err := os.Open(held)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("sleep", "60")
+	cmd.Stdin = f
+	err = cmd.Start()
+	f.Close()
+	if err != nil {
+		t.Skipf("cannot start a holder process: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	results, err := delf.AnnotateOpenFiles([]delf.Result{{Path: held}, {Path: closed}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted, failed, skipped, _ := performDeletion(context.Background(), results, delf.PermanentDeleter{}, nil)
+	if deleted != 1 || failed != 0 || skipped != 1 {
+		t.Fatalf("performDeletion = %d deleted, %d failed, %d skipped", deleted, failed, skipped)
+	}
+	if _, err := os.Stat(held); err != nil {
+		t.Fatal("file held open was deleted with --skip-busy")
+	}
+	if _, err := os.Stat(closed); !os.IsNotExist(err) {
+		t.Fatal("closed file was not deleted")
+	}
+	if e := recorder.Events(delf.EventSkipped); len(e) != 1 || e[0].Path != held {
+		t.Fatalf("skipped events = %v", e)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// recordRun points the CLI's output at a buffer and its events at a recorder,
+// restoring the globals when the test ends
+func recordRun(t *testing.T) (*bytes.Buffer, *delf.Recorder) {
+	t.Helper()
+	savedConsole, savedSink, savedOpts := console, sink, opts
+	t.Cleanup(func() { console, sink, opts = savedConsole, savedSink, savedOpts })
+
+	initColors()
+	var out bytes.Buffer
+	recorder := &delf.Recorder{}
+	console, sink = &out, recorder
+	return &out, recorder
+}
+
+// touchFile creates a file with some content, making parent directories
+func touchFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// failingDeleter removes paths, except those named "locked"
+type failingDeleter struct{ delf.PermanentDeleter }
+
+func (d failingDeleter) Delete(result delf.Result) error {
+	if filepath.Base(result.Path) == "locked" {
+		return errors.New("permission denied")
+	}
+	return d.PermanentDeleter.Delete(result)
+}
+
+func TestPerformDeletionEvents(t *testing.T) {
+	out, recorder := recordRun(t)
+	opts.SkipBusy = true
+
+	root := t.TempDir()
+	deleted := filepath.Join(root, "a.tmp")
+	busy := filepath.Join(root, "busy.tmp")
+	locked := filepath.Join(root, "locked")
+	for _, path := range []string{deleted, busy, locked} {
+		touchFile(t, path)
+	}
+	results := []delf.Result{
+		{Path: deleted},
+		{Path: filepath.Join(root, "gone.tmp")},
+		{Path: busy, Holders: []delf.Holder{{PID: 42, Command: "tail"}}},
+		{Path: locked},
+	}
+
+	nDeleted, nFailed, nSkipped, left := performDeletion(context.Background(), results, failingDeleter{}, nil)
+	if nDeleted != 1 || nFailed != 1 || nSkipped != 1 || left != nil {
+		t.Fatalf("performDeletion = %d deleted, %d failed, %d skipped, %d left", nDeleted, nFailed, nSkipped, len(left))
+	}
+
+	var got []string
+	for _, e := range recorder.Events() {
+		got = append(got, e.Kind.String()+" "+filepath.Base(e.Path))
+	}
+	want := []string{"deleted a.tmp", "skipped busy.tmp", "failed locked"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %q, want %q", got, want)
+	}
+	if e := recorder.Events(delf.EventDeleted)[0]; e.Verb != "Deleted" {
+		t.Fatalf("deleted event verb = %q", e.Verb)
+	}
+	if e := recorder.Events(delf.EventSkipped)[0]; !strings.Contains(e.Reason, "tail") {
+		t.Fatalf("skipped event reason = %q", e.Reason)
+	}
+
+	if _, err := os.Stat(deleted); !os.IsNotExist(err) {
+		t.Fatal("deleted file is still there")
+	}
+	if _, err := os.Stat(busy); err != nil {
+		t.Fatal("busy file was removed with --skip-busy")
+	}
+	if !strings.Contains(out.String(), "Deleting...") {
+		t.Fatalf("heading missing from console output %q", out.String())
+	}
+}
+
+func TestPerformDeletionInterrupted(t *testing.T) {
+	_, recorder := recordRun(t)
+
+	root := t.TempDir()
+	results := []delf.Result{{Path: filepath.Join(root, "a.tmp")}, {Path: filepath.Join(root, "b.tmp")}}
+	for _, r := range results {
+		touchFile(t, r.Path)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	nDeleted, _, _, left := performDeletion(ctx, results, delf.PermanentDeleter{}, nil)
+	if nDeleted != 0 || len(left) != len(results) {
+		t.Fatalf("cancelled run deleted %d and left %d", nDeleted, len(left))
+	}
+	if events := recorder.Events(); len(events) != 0 {
+		t.Fatalf("cancelled run sent events %v", events)
+	}
+	for _, r := range results {
+		if _, err := os.Stat(r.Path); err != nil {
+			t.Fatalf("%s removed after cancellation", r.Path)
+		}
+	}
+}
+
+func TestPerformDeletionDanglingSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	recordRun(t)
+
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	link := filepath.Join(root, "link")
+	touchFile(t, filepath.Join(target, "a.log"))
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the directory first leaves the link dangling, but it was archived too
+	results := []delf.Result{{Path: target, IsDir: true}, {Path: link}}
+	archive := &delf.ArchiveDeleter{Path: filepath.Join(t.TempDir(), "out.tar"), Root: root}
+	deleted, failed, _, _ := performDeletion(context.Background(), results, archive, nil)
+	if deleted != 2 || failed != 0 {
+		t.Fatalf("performDeletion = %d deleted, %d failed, want 2 deleted", deleted, failed)
+	}
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		t.Fatal("archived dangling symlink was left on disk")
+	}
+}
+
+func TestJSONSinkLeavesStdoutAlone(t *testing.T) {
+	recordRun(t)
+	opts.JSON = true
+	stdout := os.Stdout
+	if _, ok := newSink().(*terminalSink); ok {
+		t.Fatal("--json picked the terminal sink")
+	}
+	if os.Stdout != stdout {
+		t.Fatal("newSink replaced os.Stdout")
+	}
+}
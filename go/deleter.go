@@ -0,0 +1,31 @@
+package main
+
+import "github.com/ReggieAlbiosA/delf/pkg/delf"
+
+// deleteOptions maps the deletion flags onto the library options. With several
+// search roots, archive and quarantine paths are relative to their common parent.
+func deleteOptions() delf.DeleteOptions {
+	root := opts.Path
+	roots := []string{opts.Path}
+	if many := searchRoots(); many != nil {
+		root, roots = delf.CommonRoot(many), many
+	}
+	if pathList != nil {
+		// A path list is not searched, so only the listed paths rule out an archive location
+		roots = nil
+	}
+	return delf.DeleteOptions{
+		Root:    root,
+		Roots:   roots,
+		Trash:   opts.Trash,
+		Archive: opts.Archive,
+		MoveTo:  opts.MoveTo,
+		Shred:   opts.Shred,
+		DryRun:  opts.DryRun,
+	}
+}
+
+// newDeleter returns the deleter selected by the command-line flags
+func newDeleter() (delf.Deleter, error) {
+	return delf.NewDeleter(deleteOptions())
+}
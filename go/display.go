@@ -8,6 +8,8 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/mattn/go-isatty"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
 )
 
 // Colors holds color functions for output
@@ -64,16 +66,16 @@ func initColors() {
 func showHeader() {
 	cyan := colors.Cyan
 	bold := colors.Bold
-	fmt.Println(bold(cyan("delf - Delete Folder/File")) + " v" + Version)
-	fmt.Println(bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
-	fmt.Println()
+	fmt.Fprintln(console, bold(cyan("delf - Delete Folder/File"))+" v"+Version)
+	fmt.Fprintln(console, bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintln(console)
 }
 
 // showResult displays a single search result with safety indicator
-func showResult(filePath string, count int, category FileCategory) {
+func showResult(filePath string, count int, category delf.Category) {
 	info, err := os.Lstat(filePath)
 	if err != nil {
-		fmt.Printf("  [%d] %s\n", count, filePath)
+		fmt.Fprintf(console, "  [%d] %s\n", count, filePath)
 		return
 	}
 
@@ -84,10 +86,10 @@ func showResult(filePath string, count int, category FileCategory) {
 	var icon string
 	var colorFunc func(format string, a ...interface{}) string
 	switch category {
-	case CategoryCritical:
+	case delf.CategoryCritical:
 		icon = "!!!"
 		colorFunc = colors.BoldRed
-	case CategoryWarning:
+	case delf.CategoryWarning:
 		icon = "! "
 		colorFunc = colors.Yellow
 	default:
@@ -103,7 +105,7 @@ func showResult(filePath string, count int, category FileCategory) {
 		typeIndicator = filePath
 	}
 
-	fmt.Printf("%s %s%s\n",
+	fmt.Fprintf(console, "%s %s%s\n",
 		colorFunc(fmt.Sprintf("  %s", icon)),
 		colorFunc(typeIndicator),
 		fileInfo)
@@ -112,13 +114,13 @@ func showResult(filePath string, count int, category FileCategory) {
 // showMatchResult displays a result for deletion preview
 func showMatchResult(filePath string, isDir bool) {
 	if isDir {
-		fmt.Printf("%s %s%c%s\n",
+		fmt.Fprintf(console, "%s %s%c%s\n",
 			colors.Red("  [D]"),
 			colors.Red(filePath),
 			filepath.Separator,
 			colors.Dim(""))
 	} else {
-		fmt.Printf("%s %s\n",
+		fmt.Fprintf(console, "%s %s\n",
 			colors.Red("  [F]"),
 			colors.Red(filePath))
 	}
@@ -167,176 +169,294 @@ func formatSize(bytes int64) string {
 	}
 }
 
-// parseSize converts size string like "100M" to bytes
-func parseSize(sizeStr string) (int64, error) {
-	sizeStr = strings.TrimSpace(strings.ToUpper(sizeStr))
-	if sizeStr == "" {
-		return 0, fmt.Errorf("empty size string")
+// showSearchInfo displays search parameters
+func showSearchInfo(roots []string, pattern string, usingFd bool) {
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("Searching..."))
+	if len(roots) == 1 {
+		fmt.Fprintf(console, "%s %s\n", colors.Blue("Path:"), colors.Cyan(roots[0]))
+	} else {
+		fmt.Fprintf(console, "%s %s\n", colors.Blue("Paths:"), colors.Cyan(strings.Join(roots, ", ")))
 	}
+	fmt.Fprintf(console, "%s %s\n", colors.Blue("Pattern:"), colors.Yellow(pattern))
 
-	var multiplier int64 = 1
-	var numStr string
-
-	lastChar := sizeStr[len(sizeStr)-1]
-	switch lastChar {
-	case 'K':
-		multiplier = 1024
-		numStr = sizeStr[:len(sizeStr)-1]
-	case 'M':
-		multiplier = 1024 * 1024
-		numStr = sizeStr[:len(sizeStr)-1]
-	case 'G':
-		multiplier = 1024 * 1024 * 1024
-		numStr = sizeStr[:len(sizeStr)-1]
-	case 'B':
-		multiplier = 1
-		numStr = sizeStr[:len(sizeStr)-1]
-	default:
-		if lastChar >= '0' && lastChar <= '9' {
-			numStr = sizeStr
-		} else {
-			return 0, fmt.Errorf("invalid size unit: %c", lastChar)
-		}
+	if usingFd {
+		fmt.Fprintf(console, "%s %s\n", colors.Blue("Method:"), colors.Green("fd (parallel search)"))
+	} else {
+		fmt.Fprintf(console, "%s %s\n", colors.Blue("Method:"), colors.Yellow("walk (install 'fd' for faster search)"))
 	}
+	fmt.Fprintln(console)
+}
 
-	var num int64
-	_, err := fmt.Sscanf(numStr, "%d", &num)
-	if err != nil {
-		return 0, fmt.Errorf("invalid size number: %s", numStr)
+// showListInfo displays where a path list is read from
+func showListInfo(source, pattern string) {
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("Reading paths..."))
+	fmt.Fprintf(console, "%s %s\n", colors.Blue("From:"), colors.Cyan(source))
+	if pattern != "" {
+		fmt.Fprintf(console, "%s %s\n", colors.Blue("Pattern:"), colors.Yellow(pattern))
 	}
-
-	return num * multiplier, nil
+	fmt.Fprintln(console)
 }
 
-// showSearchInfo displays search parameters
-func showSearchInfo(searchPath, pattern string, usingFd bool) {
-	fmt.Println()
-	fmt.Println(colors.Bold("Searching..."))
-	fmt.Printf("%s %s\n", colors.Blue("Path:"), colors.Cyan(searchPath))
-	fmt.Printf("%s %s\n", colors.Blue("Pattern:"), colors.Yellow(pattern))
-
-	if usingFd {
-		fmt.Printf("%s %s\n", colors.Blue("Method:"), colors.Green("fd (parallel search)"))
-	} else {
-		fmt.Printf("%s %s\n", colors.Blue("Method:"), colors.Yellow("walk (install 'fd' for faster search)"))
+// showNoMatches reports a search that matched nothing
+func showNoMatches() {
+	fmt.Fprintln(console)
+	if pathList != nil && opts.Pattern == "" {
+		fmt.Fprintln(console, colors.Yellow(colors.Bold("No usable paths in the list")))
+		return
+	}
+	fmt.Fprintf(console, "%s for pattern: %s\n",
+		colors.Yellow(colors.Bold("No matches found")),
+		colors.Cyan(opts.Pattern))
+	if !opts.All {
+		fmt.Fprintf(console, "%s Auto-exclusions are enabled. Use %s flag to disable.\n",
+			colors.Yellow("Note:"),
+			colors.Cyan("-a"))
 	}
-	fmt.Println()
 }
 
-// showMatchSummary displays summary of matched files by category
-func showMatchSummary(total, critical, warning, safe int) {
-	fmt.Println()
-	fmt.Println(colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
-	fmt.Printf("%s %s%s\n", colors.Bold("Found"), colors.Yellow(fmt.Sprintf("%d", total)), colors.Bold(" total matches"))
+// showMatchSummary displays summary of matched files by category, and how many
+// matches each search root contributed when there were several.
+// total counts every match; results are what remains after nested matches are folded.
+func showMatchSummary(total int, results []delf.Result, critical, warning, safe int) {
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	if len(results) < total {
+		fmt.Fprintf(console, "%s %s%s %s%s\n", colors.Bold("Found"), colors.Yellow(fmt.Sprintf("%d", total)),
+			colors.Bold(" items in"), colors.Yellow(fmt.Sprintf("%d", len(results))), colors.Bold(" top-level matches"))
+	} else {
+		fmt.Fprintf(console, "%s %s%s\n", colors.Bold("Found"), colors.Yellow(fmt.Sprintf("%d", total)), colors.Bold(" total matches"))
+	}
 
 	if critical > 0 {
-		fmt.Printf("  %s %d\n", colors.BoldRed("!!! Critical system files:"), critical)
+		fmt.Fprintf(console, "  %s %d\n", colors.BoldRed("!!! Critical system files:"), critical)
 	}
 	if warning > 0 {
-		fmt.Printf("  %s %d\n", colors.Yellow("!  Warning-level files:"), warning)
+		fmt.Fprintf(console, "  %s %d\n", colors.Yellow("!  Warning-level files:"), warning)
 	}
 	if safe > 0 {
-		fmt.Printf("  %s %d\n", colors.Green("OK Safe files:"), safe)
+		fmt.Fprintf(console, "  %s %d\n", colors.Green("OK Safe files:"), safe)
+	}
+
+	// Per-root subtotals, counting the matches folded into each result
+	if roots := searchRoots(); roots != nil {
+		byRoot := make(map[string]int)
+		for _, r := range results {
+			byRoot[r.Root] += 1 + r.Nested
+		}
+		fmt.Fprintln(console, colors.Bold("By search path:"))
+		for _, root := range delf.DedupeRoots(roots) {
+			fmt.Fprintf(console, "  %s %s\n", colors.Yellow(fmt.Sprintf("%6d", byRoot[root])), root)
+		}
 	}
 }
 
 // showCriticalWarning displays a critical system warning
 func showCriticalWarning(criticalCount int) {
-	fmt.Println()
-	fmt.Println(colors.BoldRed("!!! CRITICAL DANGER WARNING !!!"))
-	fmt.Println(colors.BoldRed("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
-	fmt.Printf("%s %d %s\n", colors.BoldRed("You are about to delete"), criticalCount, colors.BoldRed("SYSTEM FILES!"))
-	fmt.Println()
-	fmt.Println(colors.Yellow(colors.Bold("CONSEQUENCES:")))
-	fmt.Println(colors.Red("  - May break Windows boot"))
-	fmt.Println(colors.Red("  - May break critical services"))
-	fmt.Println(colors.Red("  - May make the system unrecoverable"))
-	fmt.Println(colors.Red("  - May require Windows reinstallation"))
-	fmt.Println()
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.BoldRed("!!! CRITICAL DANGER WARNING !!!"))
+	fmt.Fprintln(console, colors.BoldRed("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintf(console, "%s %d %s\n", colors.BoldRed("You are about to delete"), criticalCount, colors.BoldRed("SYSTEM FILES!"))
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Yellow(colors.Bold("CONSEQUENCES:")))
+	fmt.Fprintln(console, colors.Red("  - May break Windows boot"))
+	fmt.Fprintln(console, colors.Red("  - May break critical services"))
+	fmt.Fprintln(console, colors.Red("  - May make the system unrecoverable"))
+	fmt.Fprintln(console, colors.Red("  - May require Windows reinstallation"))
+	fmt.Fprintln(console)
 }
 
 // showNoPermissionWarning displays permission warning for system files
 func showNoPermissionWarning(criticalCount int) {
-	fmt.Println()
-	fmt.Println(colors.BoldRed("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
-	fmt.Printf("%s %d %s\n", colors.BoldRed("!!! DANGER:"), criticalCount, colors.BoldRed("files are CRITICAL SYSTEM FILES!"))
-	fmt.Println(colors.BoldRed("X Cannot delete (insufficient permissions)"))
-	fmt.Println(colors.Yellow("Run as Administrator if you really need to delete system files"))
-	fmt.Println(colors.BoldRed("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.BoldRed("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintf(console, "%s %d %s\n", colors.BoldRed("!!! DANGER:"), criticalCount, colors.BoldRed("files are CRITICAL SYSTEM FILES!"))
+	fmt.Fprintln(console, colors.BoldRed("X Cannot delete (insufficient permissions)"))
+	fmt.Fprintln(console, colors.Yellow("Run as Administrator if you really need to delete system files"))
+	fmt.Fprintln(console, colors.BoldRed("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
 }
 
 // showDeletionProgress displays deletion progress
-func showDeletionProgress(deleted, failed int) {
-	fmt.Println()
-	fmt.Println(colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
-	fmt.Printf("%s %d items\n", colors.Green(colors.Bold("OK Deleted:")), deleted)
+func showDeletionProgress(verb string, deleted, failed, skipped int) {
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintf(console, "%s %d items\n", colors.Green(colors.Bold("OK "+verb+":")), deleted)
 	if failed > 0 {
-		fmt.Printf("%s %d items (try running as Administrator)\n", colors.BoldRed("X Failed:"), failed)
+		fmt.Fprintf(console, "%s %d items (try running as Administrator)\n", colors.BoldRed("X Failed:"), failed)
+	}
+	if skipped > 0 {
+		fmt.Fprintf(console, "%s %d items (in use by running processes)\n", colors.Yellow("! Skipped:"), skipped)
+	}
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+}
+
+// showInterrupted lists what an interrupted deletion never reached
+func showInterrupted(left []delf.Result) {
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "%s %d items were not touched:\n", colors.Yellow(colors.Bold("Interrupted:")), len(left))
+	for i, result := range left {
+		if i == 10 {
+			fmt.Fprintf(console, "%s\n", colors.Yellow(fmt.Sprintf("  ... and %d more", len(left)-10)))
+			break
+		}
+		fmt.Fprintf(console, "  %s\n", result.Path)
+	}
+}
+
+// showShredWarnings displays the shred pass count and storage caveats
+func showShredWarnings(warnings []string, passes int) {
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "%s %d overwrite passes before unlinking\n", colors.Magenta("Shred:"), passes)
+	for _, w := range warnings {
+		fmt.Fprintf(console, "%s %s\n", colors.Yellow("Warning:"), w)
 	}
-	fmt.Println(colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
 }
 
 // showDryRunNotice displays dry-run mode notice
 func showDryRunNotice() {
-	fmt.Println()
-	fmt.Printf("%s No files were deleted\n", colors.Yellow(colors.Bold("DRY-RUN MODE:")))
-	fmt.Printf("Remove %s flag to actually delete these files\n", colors.Cyan("-n"))
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "%s No files were deleted\n", colors.Yellow(colors.Bold("DRY-RUN MODE:")))
+	fmt.Fprintf(console, "Remove %s flag to actually delete these files\n", colors.Cyan("-n"))
 }
 
 // showHelp displays the help message
 func showHelp() {
-	fmt.Printf("%s v%s\n", colors.Bold("delf - Delete Folder/File Command"), Version)
-	fmt.Println()
-	fmt.Println(colors.Bold("USAGE:"))
-	fmt.Println("    delf [OPTIONS] [PATTERN] [PATH]")
-	fmt.Println()
-	fmt.Println(colors.Bold("DESCRIPTION:"))
-	fmt.Println("    Interactive tool to find and delete files/folders with pattern matching,")
-	fmt.Println("    exclusions, and safety features.")
-	fmt.Println()
-	fmt.Println(colors.Bold("OPTIONS:"))
-	fmt.Printf("    %s               Show this help message\n", colors.Cyan("-h, --help"))
-	fmt.Printf("    %s             Preview only, don't delete anything\n", colors.Cyan("-n, --dry-run"))
-	fmt.Printf("    %s              Skip all confirmations (dangerous!)\n", colors.Cyan("-f, --force"))
-	fmt.Printf("    %s                  Case-insensitive pattern matching\n", colors.Cyan("-i"))
-	fmt.Printf("    %s           Filter by type: %s(file) or %s(directory)\n",
+	fmt.Fprintf(console, "%s v%s\n", colors.Bold("delf - Delete Folder/File Command"), Version)
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("USAGE:"))
+	fmt.Fprintln(console, "    delf [OPTIONS] [PATTERN] [PATH...]")
+	fmt.Fprintln(console, "    delf log [OPTIONS]")
+	fmt.Fprintln(console, "    delf restore [OPTIONS] MANIFEST")
+	fmt.Fprintln(console, "    delf dupes [OPTIONS] [PATH]")
+	fmt.Fprintln(console, "    delf du [OPTIONS] [PATH]")
+	fmt.Fprintln(console, "    delf run [OPTIONS] RULES")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("DESCRIPTION:"))
+	fmt.Fprintln(console, "    Interactive tool to find and delete files/folders with pattern matching,")
+	fmt.Fprintln(console, "    exclusions, and safety features.")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("OPTIONS:"))
+	fmt.Fprintf(console, "    %s               Show this help message\n", colors.Cyan("-h, --help"))
+	fmt.Fprintf(console, "    %s             Preview only, don't delete anything\n", colors.Cyan("-n, --dry-run"))
+	fmt.Fprintf(console, "    %s              Skip all confirmations (dangerous!)\n", colors.Cyan("-f, --force"))
+	fmt.Fprintf(console, "    %s    Pattern to match; every positional argument is a PATH\n", colors.Cyan("-p, --pattern PAT"))
+	fmt.Fprintln(console, "                         (e.g. -p log, since 'delf log' runs the log subcommand)")
+	fmt.Fprintf(console, "    %s                  Case-insensitive pattern matching\n", colors.Cyan("-i"))
+	fmt.Fprintf(console, "    %s           Filter by type: %s(file) or %s(directory)\n",
 		colors.Cyan("-t TYPE"), colors.Yellow("f"), colors.Yellow("d"))
-	fmt.Printf("    %s                  Disable auto-exclusion of common directories\n", colors.Cyan("-a"))
-	fmt.Printf("    %s          Display total size of matched files\n", colors.Cyan("--show-size"))
-	fmt.Printf("    %s    Only match files older than N days\n", colors.Cyan("--older-than DAYS"))
-	fmt.Printf("    %s   Only match files larger than SIZE (K,M,G)\n", colors.Cyan("--larger-than SIZE"))
-	fmt.Printf("    %s         Find and delete empty directories only\n", colors.Cyan("--empty-dirs"))
-	fmt.Printf("    %s   Maximum results to display (default: 100)\n", colors.Cyan("--max-display NUM"))
-	fmt.Println()
-	fmt.Println(colors.Bold("EXAMPLES:"))
-	fmt.Printf("    %s\n", colors.Green("# Delete all .log files"))
-	fmt.Println("    delf \"*.log\"")
-	fmt.Println()
-	fmt.Printf("    %s\n", colors.Green("# Preview what would be deleted (dry-run)"))
-	fmt.Println("    delf -n \"*.tmp\"")
-	fmt.Println()
-	fmt.Printf("    %s\n", colors.Green("# Delete large video files older than 30 days"))
-	fmt.Println("    delf --older-than 30 --larger-than 100M \"*.mp4\"")
-	fmt.Println()
-	fmt.Printf("    %s\n", colors.Green("# Delete only directories named 'dist'"))
-	fmt.Println("    delf -t d dist")
-	fmt.Println()
-	fmt.Printf("    %s\n", colors.Green("# Delete empty directories"))
-	fmt.Println("    delf --empty-dirs")
-	fmt.Println()
-	fmt.Println(colors.Bold("AUTO-EXCLUDED DIRECTORIES:"))
-	fmt.Println("    By default, these patterns are protected (use -a to disable):")
-	fmt.Println("    - node_modules, .git, .npm, .cache, .vscode, .idea")
-	fmt.Println()
-	fmt.Println(colors.Bold("SAFETY FEATURES:"))
-	fmt.Println("    - Critical system path protection (C:\\Windows, Program Files, etc.)")
-	fmt.Println("    - Auto-exclusion of important directories")
-	fmt.Println("    - Preview before deletion")
-	fmt.Println("    - Dry-run mode for testing")
-	fmt.Println()
-	fmt.Println(colors.Bold("PERFORMANCE:"))
-	fmt.Println("    - Uses 'fd' for fast parallel searching (if installed)")
-	fmt.Println("    - Falls back to Go's filepath.WalkDir if fd is not available")
-	fmt.Printf("    - Install fd: %s\n", colors.Cyan("winget install sharkdp.fd"))
-	fmt.Println()
+	fmt.Fprintf(console, "    %s                  Disable auto-exclusion of common directories\n", colors.Cyan("-a"))
+	fmt.Fprintf(console, "    %s          Display total size of matched files\n", colors.Cyan("--show-size"))
+	fmt.Fprintf(console, "    %s     Only match entries older than AGE or DATE\n", colors.Cyan("--older-than AGE"))
+	fmt.Fprintln(console, "                         (30 = days, 2h, 3w, 6mo, 1y, 2024-01-31, RFC 3339)")
+	fmt.Fprintf(console, "    %s     Only match entries newer than AGE or DATE\n", colors.Cyan("--newer-than AGE"))
+	fmt.Fprintf(console, "    %s   Timestamp for age filters: mtime (default), atime,\n", colors.Cyan("--time-field FIELD"))
+	fmt.Fprintln(console, "                         ctime or btime (birth time)")
+	fmt.Fprintf(console, "    %s   Only match files larger than SIZE\n", colors.Cyan("--larger-than SIZE"))
+	fmt.Fprintln(console, "                         (1.5G, 10K/10KiB binary, 10KB decimal; up to P)")
+	fmt.Fprintf(console, "    %s  Only match files smaller than SIZE\n", colors.Cyan("--smaller-than SIZE"))
+	fmt.Fprintf(console, "    %s      Only match sizes in a range (10M..1G, 10M.., ..1G)\n", colors.Cyan("--size MIN..MAX"))
+	fmt.Fprintf(console, "    %s           Apply size filters to directory totals too\n", colors.Cyan("--dir-size"))
+	fmt.Fprintf(console, "    %s          Only match entries owned by USER (or --uid N)\n", colors.Cyan("--user USER"))
+	fmt.Fprintf(console, "    %s        Only match entries owned by GROUP (or --gid N)\n", colors.Cyan("--group GROUP"))
+	fmt.Fprintf(console, "    %s             Only match entries whose owner has no account\n", colors.Cyan("--nouser"))
+	fmt.Fprintf(console, "    %s          Only match permission bits: 644 exactly, -o+w all of,\n", colors.Cyan("--perm MODE"))
+	fmt.Fprintln(console, "                         /111 any of")
+	fmt.Fprintf(console, "    %s          Only match files whose content is TYPE (image/*, ...)\n", colors.Cyan("--mime TYPE"))
+	fmt.Fprintf(console, "    %s          Only match text, binary, archive or media files\n", colors.Cyan("--kind KIND"))
+	fmt.Fprintf(console, "    %s         Only match executable files\n", colors.Cyan("--executable"))
+	fmt.Fprintf(console, "    %s     Only match text files with a line matching REGEX\n", colors.Cyan("--contains REGEX"))
+	fmt.Fprintf(console, "    %s         Find and delete empty directories only\n", colors.Cyan("--empty-dirs"))
+	fmt.Fprintf(console, "    %s        Descend at most N levels below PATH\n", colors.Cyan("--max-depth N"))
+	fmt.Fprintf(console, "    %s        Only match entries at least N levels below PATH\n", colors.Cyan("--min-depth N"))
+	fmt.Fprintf(console, "    %s              Don't descend into matched directories\n", colors.Cyan("--prune"))
+	fmt.Fprintf(console, "    %s            Stop searching after N results\n", colors.Cyan("--limit N"))
+	fmt.Fprintf(console, "    %s             Fail instead of skipping unreadable directories and files\n", colors.Cyan("--strict"))
+	fmt.Fprintf(console, "    %s           Also search DIR; repeat for more (same as extra PATHs)\n", colors.Cyan("--root DIR"))
+	fmt.Fprintf(console, "    %s              Read the paths to delete from stdin instead of searching\n", colors.Cyan("--stdin"))
+	fmt.Fprintf(console, "    %s     Read the paths to delete from LIST (- for stdin)\n", colors.Cyan("--from-file LIST"))
+	fmt.Fprintf(console, "    %s           Paths in the list are NUL-separated (find -print0)\n", colors.Cyan("-0, --null"))
+	fmt.Fprintf(console, "    %s   Maximum results to display (default: 100)\n", colors.Cyan("--max-display NUM"))
+	fmt.Fprintf(console, "    %s               Write results and outcomes to stdout as JSON lines\n", colors.Cyan("--json"))
+	fmt.Fprintf(console, "    %s          Don't list matches or deletions, only summaries\n", colors.Cyan("-q, --quiet"))
+	fmt.Fprintf(console, "    %s               Only print the paths that would be deleted, one per line\n", colors.Cyan("--list"))
+	fmt.Fprintf(console, "    %s             Like --list, but NUL-terminated (for xargs -0)\n", colors.Cyan("--print0"))
+	fmt.Fprintf(console, "    %s              Rank matches by size, list the N largest and pick\n", colors.Cyan("--top N"))
+	fmt.Fprintf(console, "    %s      Never delete the N most recent matches\n", colors.Cyan("--keep-newest N"))
+	fmt.Fprintf(console, "    %s      Never delete the N oldest matches\n", colors.Cyan("--keep-oldest N"))
+	fmt.Fprintf(console, "    %s       Keep the newest match of each of the last N days\n", colors.Cyan("--keep-daily N"))
+	fmt.Fprintf(console, "    %s      Keep the newest match of each of the last N weeks\n", colors.Cyan("--keep-weekly N"))
+	fmt.Fprintf(console, "    %s     Keep the newest match of each of the last N months\n", colors.Cyan("--keep-monthly N"))
+	fmt.Fprintf(console, "    %s     Apply the keep rules per group of paths sharing\n", colors.Cyan("--group-by REGEX"))
+	fmt.Fprintln(console, "                         the REGEX capture groups")
+	fmt.Fprintln(console, "                         Keep rules rank only matches that pass the age,")
+	fmt.Fprintln(console, "                         size and other filters")
+	fmt.Fprintf(console, "    %s           Inside git repos, only delete ignored and untracked files\n", colors.Cyan("--git-safe"))
+	fmt.Fprintf(console, "    %s          Skip files open by a running process (Linux)\n", colors.Cyan("--skip-busy"))
+	fmt.Fprintf(console, "    %s     Overwrite contents before deleting (default: %d passes)\n", colors.Cyan("--shred[=PASSES]"), delf.DefaultShredPasses)
+	fmt.Fprintf(console, "    %s     Shred with N overwrite passes\n", colors.Cyan("--shred-passes N"))
+	fmt.Fprintf(console, "    %s              Move matches to the trash / Recycle Bin instead\n", colors.Cyan("--trash"))
+	fmt.Fprintf(console, "    %s       Archive and verify matches before deleting\n", colors.Cyan("--archive FILE"))
+	fmt.Fprintln(console, "                         (.tar, .tar.gz, .tar.zst, .tar.xz or .zip)")
+	fmt.Fprintf(console, "    %s        Move matches into DIR, keeping their relative paths\n", colors.Cyan("--move-to DIR"))
+	fmt.Fprintln(console, "                         (writes a manifest for delf restore)")
+	fmt.Fprintf(console, "    %s           Don't record deletions in the audit log\n", colors.Cyan("--no-audit"))
+	fmt.Fprintf(console, "    %s     Audit log file (default: $XDG_STATE_HOME/delf/audit.log)\n", colors.Cyan("--audit-log FILE"))
+	fmt.Fprintf(console, "    %s         Record the SHA-256 of each deleted file\n", colors.Cyan("--audit-hash"))
+	fmt.Fprintf(console, "    %s             Also forward audit records to syslog/journald\n", colors.Cyan("--syslog"))
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("EXAMPLES:"))
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Delete all .log files"))
+	fmt.Fprintln(console, "    delf \"*.log\"")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Search several trees at once"))
+	fmt.Fprintln(console, "    delf -t d node_modules ~/projects ~/work")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Keep the 5 newest backups of each database"))
+	fmt.Fprintln(console, "    delf --keep-newest 5 --group-by '^(.*)-\\d{8}\\.sql\\.gz$' \"*.sql.gz\" /backups")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Delete paths computed by another tool"))
+	fmt.Fprintln(console, "    find . -name '*.orig' -print0 | delf --stdin -0")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Feed safe matches to another tool"))
+	fmt.Fprintln(console, "    delf --print0 --older-than 30 \"*.log\" /var/log/app | xargs -0 gzip")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Preview what would be deleted (dry-run)"))
+	fmt.Fprintln(console, "    delf -n \"*.tmp\"")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Delete large video files older than 30 days"))
+	fmt.Fprintln(console, "    delf --older-than 30 --larger-than 100M \"*.mp4\"")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Delete downloads not opened for 6 months"))
+	fmt.Fprintln(console, "    delf --older-than 6mo --time-field atime \"*\" ~/Downloads")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Delete build directories over 500M"))
+	fmt.Fprintln(console, "    delf -t d --dir-size --larger-than 500M build")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Delete world-writable temp files owned by alice"))
+	fmt.Fprintln(console, "    delf --user alice --perm -o+w \"*.tmp\" /srv/shared")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Delete only directories named 'dist'"))
+	fmt.Fprintln(console, "    delf -t d dist")
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "    %s\n", colors.Green("# Delete empty directories"))
+	fmt.Fprintln(console, "    delf --empty-dirs")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("AUTO-EXCLUDED DIRECTORIES:"))
+	fmt.Fprintln(console, "    By default, these patterns are protected (use -a to disable):")
+	fmt.Fprintln(console, "    - node_modules, .git, .npm, .cache, .vscode, .idea")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("SAFETY FEATURES:"))
+	fmt.Fprintln(console, "    - Critical system path protection (C:\\Windows, Program Files, etc.)")
+	fmt.Fprintln(console, "    - Auto-exclusion of important directories")
+	fmt.Fprintln(console, "    - Preview before deletion")
+	fmt.Fprintln(console, "    - Dry-run mode for testing")
+	fmt.Fprintln(console, "    - Append-only audit log of every deletion (delf log)")
+	fmt.Fprintln(console, "    - Git-aware mode that never deletes tracked files (--git-safe)")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("PERFORMANCE:"))
+	fmt.Fprintln(console, "    - Uses 'fd' for fast parallel searching (if installed)")
+	fmt.Fprintln(console, "    - Falls back to Go's filepath.WalkDir if fd is not available")
+	fmt.Fprintf(console, "    - Install fd: %s\n", colors.Cyan("winget install sharkdp.fd"))
+	fmt.Fprintln(console)
 }
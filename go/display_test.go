@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// ansi matches color escape sequences
+var ansi = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestShowMatchSummaryByRoot(t *testing.T) {
+	out, _ := recordRun(t)
+
+	base := t.TempDir()
+	b := filepath.Join(base, "b")
+	bc := filepath.Join(base, "bc")
+	nested := filepath.Join(b, "sub")
+	other := t.TempDir()
+	opts.Roots = []string{b, bc, nested, b, other}
+
+	// Results carry the deduplicated root they were found under
+	results := []delf.Result{
+		{Path: filepath.Join(b, "cache"), Root: b, IsDir: true, Nested: 2},
+		{Path: filepath.Join(b, "sub", "x.log"), Root: b},
+		{Path: filepath.Join(bc, "y.log"), Root: bc},
+	}
+	showMatchSummary(5, results, 0, 0, 3)
+
+	shown := ansi.ReplaceAllString(out.String(), "")
+	byRoot := shown[strings.Index(shown, "By search path:"):]
+	for _, want := range []string{
+		"     4 " + b + "\n",
+		"     1 " + bc + "\n",
+		"     0 " + other + "\n",
+	} {
+		if !strings.Contains(byRoot, want) {
+			t.Errorf("summary lacks %q:\n%s", want, byRoot)
+		}
+	}
+	if strings.Contains(byRoot, nested) {
+		t.Errorf("a root nested in another is listed on its own:\n%s", byRoot)
+	}
+	if n := strings.Count(byRoot, b+"\n"); n != 1 {
+		t.Errorf("%s listed %d times:\n%s", b, n, byRoot)
+	}
+	if !strings.Contains(shown, "Found 5 items in 3 top-level matches") {
+		t.Errorf("summary headline missing:\n%s", shown)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// usageEntry is a file or directory with its total size
+type usageEntry struct {
+	Path    string
+	Size    int64
+	IsDir   bool
+	ModTime time.Time
+}
+
+// usageTotal accumulates bytes and file count for one aggregate row
+type usageTotal struct {
+	size  int64
+	count int
+}
+
+// ageBuckets group files by time since last modification; the last bucket is open-ended
+var ageBuckets = []struct {
+	label  string
+	maxAge time.Duration
+}{
+	{"< 1 day", 24 * time.Hour},
+	{"1-7 days", 7 * 24 * time.Hour},
+	{"1-4 weeks", 30 * 24 * time.Hour},
+	{"1-6 months", 182 * 24 * time.Hour},
+	{"6-12 months", 365 * 24 * time.Hour},
+	{"> 1 year", 0},
+}
+
+// usageReport holds the largest entries plus totals by extension and age
+type usageReport struct {
+	dirs  []usageEntry
+	files []usageEntry
+	byExt map[string]*usageTotal
+	byAge []usageTotal
+	total usageTotal
+	now   time.Time
+}
+
+func newUsageReport() *usageReport {
+	return &usageReport{
+		byExt: make(map[string]*usageTotal),
+		byAge: make([]usageTotal, len(ageBuckets)),
+		now:   time.Now(),
+	}
+}
+
+// addFile counts one file in the extension and age aggregates
+func (r *usageReport) addFile(path string, info os.FileInfo) {
+	size := info.Size()
+	r.total.size += size
+	r.total.count++
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		ext = "(none)"
+	}
+	if r.byExt[ext] == nil {
+		r.byExt[ext] = &usageTotal{}
+	}
+	r.byExt[ext].size += size
+	r.byExt[ext].count++
+
+	age := r.now.Sub(info.ModTime())
+	for i, bucket := range ageBuckets {
+		if bucket.maxAge == 0 || age < bucket.maxAge {
+			r.byAge[i].size += size
+			r.byAge[i].count++
+			break
+		}
+	}
+}
+
+// scanUsage sizes root once, totalling every directory and file below it
+func scanUsage(root string) *usageReport {
+	r := newUsageReport()
+	dirSizes := make(map[string]int64)
+	dirTimes := make(map[string]time.Time)
+
+	delf.WalkSizes(root, func(path string, info os.FileInfo) bool {
+		if !opts.All && delf.IsAutoExcluded(path) {
+			return false
+		}
+		dirTimes[path] = info.ModTime()
+		return true
+	}, func(path string, info os.FileInfo) {
+		r.addFile(path, info)
+		r.files = append(r.files, usageEntry{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+
+		// Every ancestor below the root holds this file
+		for dir := filepath.Dir(path); dir != root && len(dir) > len(root); dir = filepath.Dir(dir) {
+			dirSizes[dir] += info.Size()
+		}
+	})
+
+	for dir, modTime := range dirTimes {
+		r.dirs = append(r.dirs, usageEntry{Path: dir, Size: dirSizes[dir], IsDir: true, ModTime: modTime})
+	}
+	return r
+}
+
+// buildUsageReport sizes search results for --top, walking matched directories once
+func buildUsageReport(results []delf.Result) *usageReport {
+	r := newUsageReport()
+
+	for _, result := range results {
+		info, err := os.Stat(result.Path)
+		if err != nil {
+			continue
+		}
+		size := delf.WalkSizes(result.Path, nil, r.addFile)
+		entry := usageEntry{Path: result.Path, Size: size, IsDir: info.IsDir(), ModTime: info.ModTime()}
+		if entry.IsDir {
+			r.dirs = append(r.dirs, entry)
+		} else {
+			r.files = append(r.files, entry)
+		}
+	}
+	return r
+}
+
+// largest returns the top n entries by size
+func largest(entries []usageEntry, n int) []usageEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].Path < entries[j].Path
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// pickFromUsage shows the report and returns the entries the user picks for deletion
+func pickFromUsage(r *usageReport, top int) []delf.Result {
+	dirs := largest(r.dirs, top)
+	files := largest(r.files, top)
+	numbered := append(append([]usageEntry{}, dirs...), files...)
+
+	showUsageReport(r, dirs, files, top)
+
+	if len(numbered) == 0 {
+		fmt.Fprintln(console)
+		fmt.Fprintln(console, colors.Yellow("Nothing to pick from"))
+		os.Exit(0)
+	}
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintf(console, "%s (e.g. 1,3,5-7, or press Enter to quit):\n", colors.Cyan("Enter numbers to delete"))
+	input := readLine(colors.Cyan("> "))
+
+	picked, err := parseSelection(input, len(numbered))
+	if err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+	if len(picked) == 0 {
+		fmt.Fprintln(console, colors.Yellow("Nothing selected"))
+		os.Exit(0)
+	}
+
+	var results []delf.Result
+	for _, i := range picked {
+		entry := numbered[i]
+		results = append(results, delf.Result{
+			Path:     entry.Path,
+			Category: classifier.Classify(entry.Path),
+			IsDir:    entry.IsDir,
+		})
+	}
+	return results
+}
+
+// parseSelection turns "1,3,5-7" into zero-based indexes, in order and without repeats
+func parseSelection(input string, count int) ([]int, error) {
+	var picked []int
+	seen := make(map[int]bool)
+
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		from, to := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			from, to = part[:i], part[i+1:]
+		}
+		start, err1 := strconv.Atoi(strings.TrimSpace(from))
+		end, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || start < 1 || end > count || start > end {
+			return nil, fmt.Errorf("invalid selection: %s (choose between 1 and %d)", part, count)
+		}
+
+		for n := start; n <= end; n++ {
+			if !seen[n] {
+				seen[n] = true
+				picked = append(picked, n-1)
+			}
+		}
+	}
+	return picked, nil
+}
+
+// showUsageReport displays the largest entries and the extension and age breakdowns
+func showUsageReport(r *usageReport, dirs, files []usageEntry, top int) {
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "%s %s in %d files\n", colors.Bold("Total:"), colors.Yellow(formatSize(r.total.size)), r.total.count)
+
+	n := 0
+	if len(dirs) > 0 {
+		fmt.Fprintln(console)
+		fmt.Fprintln(console, colors.Bold("Largest directories:"))
+		for _, e := range dirs {
+			n++
+			fmt.Fprintf(console, "  %s %s  %s%c\n", colors.Cyan(fmt.Sprintf("[%2d]", n)), colors.Yellow(fmt.Sprintf("%8s", formatSize(e.Size))), e.Path, filepath.Separator)
+		}
+	}
+	if len(files) > 0 {
+		fmt.Fprintln(console)
+		fmt.Fprintln(console, colors.Bold("Largest files:"))
+		for _, e := range files {
+			n++
+			fmt.Fprintf(console, "  %s %s  %s\n", colors.Cyan(fmt.Sprintf("[%2d]", n)), colors.Yellow(fmt.Sprintf("%8s", formatSize(e.Size))), e.Path)
+		}
+	}
+
+	// Extensions by total size
+	exts := make([]string, 0, len(r.byExt))
+	for ext := range r.byExt {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		return r.byExt[exts[i]].size > r.byExt[exts[j]].size
+	})
+	if len(exts) > top {
+		exts = exts[:top]
+	}
+	if len(exts) > 0 {
+		fmt.Fprintln(console)
+		fmt.Fprintln(console, colors.Bold("By extension:"))
+		for _, ext := range exts {
+			t := r.byExt[ext]
+			fmt.Fprintf(console, "  %-12s %s  %s\n", ext, colors.Yellow(fmt.Sprintf("%8s", formatSize(t.size))), colors.Dim(fmt.Sprintf("(%d files)", t.count)))
+		}
+	}
+
+	if r.total.count > 0 {
+		fmt.Fprintln(console)
+		fmt.Fprintln(console, colors.Bold("By age (last modified):"))
+		for i, bucket := range ageBuckets {
+			t := r.byAge[i]
+			if t.count == 0 {
+				continue
+			}
+			fmt.Fprintf(console, "  %-12s %s  %s\n", bucket.label, colors.Yellow(fmt.Sprintf("%8s", formatSize(t.size))), colors.Dim(fmt.Sprintf("(%d files)", t.count)))
+		}
+	}
+}
+
+// runDuCommand implements `delf du [PATH]`
+func runDuCommand(args []string) {
+	fs := flag.NewFlagSet("du", flag.ExitOnError)
+	top := fs.Int("top", 10, "Number of directories, files and extensions to list")
+	fs.BoolVar(&opts.All, "a", false, "Include auto-excluded directories")
+	fs.BoolVar(&opts.DryRun, "n", false, "Preview only, don't delete anything")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Preview only, don't delete anything")
+	fs.Usage = showDuHelp
+	fs.Parse(args)
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	root, _ = filepath.Abs(root)
+	opts.Path = root
+	opts.MaxDisplay = 100
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("Measuring disk usage..."))
+	fmt.Fprintf(console, "%s %s\n", colors.Blue("Path:"), colors.Cyan(root))
+
+	results := pickFromUsage(scanUsage(root), *top)
+	processResults(results)
+}
+
+// showDuHelp displays usage for `delf du`
+func showDuHelp() {
+	fmt.Fprintln(console, colors.Bold("USAGE:"))
+	fmt.Fprintln(console, "    delf du [OPTIONS] [PATH]")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, "    Shows the largest directories and files under PATH, totals by extension")
+	fmt.Fprintln(console, "    and age, and lets you pick entries to delete.")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("OPTIONS:"))
+	fmt.Fprintf(console, "    %s       Entries to list per section (default: 10)\n", colors.Cyan("--top N"))
+	fmt.Fprintf(console, "    %s            Include auto-excluded directories (node_modules, .git, ...)\n", colors.Cyan("-a"))
+	fmt.Fprintf(console, "    %s  Preview only, don't delete anything\n", colors.Cyan("-n, --dry-run"))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []int
+	}{
+		{"1", []int{0}},
+		{"1,3", []int{0, 2}},
+		{"5-7", []int{4, 5, 6}},
+		{"1,3,5-7", []int{0, 2, 4, 5, 6}},
+		{" 2 , 4 - 5 ", []int{1, 3, 4}},
+		{"3,1", []int{2, 0}},
+		{"1-3,2-4,2", []int{0, 1, 2, 3}},
+		{"7-7", []int{6}},
+		{"1,,2,", []int{0, 1}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := parseSelection(tt.in, 8)
+		if err != nil {
+			t.Errorf("parseSelection(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSelection(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"0", "9", "-1", "3-2", "1-9", "a", "1-", "-", "1-2-3"} {
+		if got, err := parseSelection(in, 8); err == nil {
+			t.Errorf("parseSelection(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+// writeSized creates a file of size bytes, making parent directories
+func writeSized(t *testing.T, path string, size int) {
+	t.Helper()
+	touchFile(t, path)
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanUsage(t *testing.T) {
+	recordRun(t)
+	root := t.TempDir()
+	writeSized(t, filepath.Join(root, "a.log"), 10)
+	writeSized(t, filepath.Join(root, "logs", "b.log"), 20)
+	writeSized(t, filepath.Join(root, "logs", "old", "c.txt"), 40)
+	writeSized(t, filepath.Join(root, "node_modules", "d.js"), 80)
+
+	r := scanUsage(root)
+	if r.total.size != 70 || r.total.count != 3 {
+		t.Fatalf("total = %d bytes in %d files, want 70 in 3 (node_modules excluded)", r.total.size, r.total.count)
+	}
+	dirs := make(map[string]int64)
+	for _, e := range r.dirs {
+		rel, _ := filepath.Rel(root, e.Path)
+		dirs[filepath.ToSlash(rel)] = e.Size
+	}
+	if want := map[string]int64{"logs": 60, "logs/old": 40}; !reflect.DeepEqual(dirs, want) {
+		t.Fatalf("directory sizes = %v, want %v", dirs, want)
+	}
+	if ext := r.byExt[".log"]; ext == nil || ext.size != 30 || ext.count != 2 {
+		t.Fatalf(".log total = %+v", ext)
+	}
+
+	// --top sizes the same tree the same way
+	results := []delf.Result{{Path: filepath.Join(root, "logs"), IsDir: true}, {Path: filepath.Join(root, "a.log")}}
+	top := buildUsageReport(results)
+	if len(top.dirs) != 1 || top.dirs[0].Size != delf.PathSize(results[0].Path) {
+		t.Fatalf("--top directories = %+v", top.dirs)
+	}
+	if len(top.files) != 1 || top.files[0].Size != 10 || top.total.size != 70 {
+		t.Fatalf("--top files = %+v, total %d", top.files, top.total.size)
+	}
+}
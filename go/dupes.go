@@ -0,0 +1,462 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// partialHashSize is how much of each file is hashed before comparing whole files
+const partialHashSize = 16 * 1024
+
+// dupeFile is a candidate file for duplicate detection
+type dupeFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	info    os.FileInfo
+}
+
+// DupeGroup is a set of files with identical contents
+type DupeGroup struct {
+	Keep    dupeFile
+	Remove  []dupeFile
+	Size    int64
+	Already int // copies that are already hardlinks of the kept file
+}
+
+// findDupeCandidates walks root and buckets regular files by size, dropping unique sizes
+func findDupeCandidates(root string, minSize int64) ([][]dupeFile, error) {
+	bySize := make(map[int64][]dupeFile)
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && !opts.All && delf.IsAutoExcluded(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() < minSize {
+			return nil
+		}
+		bySize[info.Size()] = append(bySize[info.Size()], dupeFile{path: path, size: info.Size(), modTime: info.ModTime(), info: info})
+		return nil
+	})
+
+	var buckets [][]dupeFile
+	for _, files := range bySize {
+		if len(files) > 1 {
+			buckets = append(buckets, files)
+		}
+	}
+	return buckets, err
+}
+
+// hashFiles hashes files in parallel, reading at most limit bytes of each (0 = whole file)
+func hashFiles(files []dupeFile, limit int64) map[string]string {
+	jobs := make(chan string)
+	sums := make(map[string]string, len(files))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				sum, err := hashPrefix(path, limit)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				sums[path] = sum
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, f := range files {
+		jobs <- f.path
+	}
+	close(jobs)
+	wg.Wait()
+	return sums
+}
+
+// hashPrefix returns the SHA-256 of the first limit bytes of a file (0 = whole file)
+func hashPrefix(path string, limit int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if limit > 0 {
+		r = io.LimitReader(f, limit)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// regroup splits each bucket by the hash of its files, dropping unique entries
+func regroup(buckets [][]dupeFile, limit int64) [][]dupeFile {
+	var all []dupeFile
+	for _, b := range buckets {
+		all = append(all, b...)
+	}
+	sums := hashFiles(all, limit)
+
+	groups := make(map[string][]dupeFile)
+	for _, b := range buckets {
+		for _, f := range b {
+			if sum, ok := sums[f.path]; ok {
+				key := fmt.Sprintf("%d:%s", f.size, sum)
+				groups[key] = append(groups[key], f)
+			}
+		}
+	}
+
+	var next [][]dupeFile
+	for _, files := range groups {
+		if len(files) > 1 {
+			next = append(next, files)
+		}
+	}
+	return next
+}
+
+// findDupes narrows candidates by size, then partial hash, then full hash
+func findDupes(root string, minSize int64) ([]DupeGroup, error) {
+	buckets, err := findDupeCandidates(root, minSize)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets = regroup(buckets, partialHashSize)
+
+	// Files no bigger than the partial hash are already fully compared
+	var same, large [][]dupeFile
+	for _, b := range buckets {
+		if b[0].size <= partialHashSize {
+			same = append(same, b)
+		} else {
+			large = append(large, b)
+		}
+	}
+	same = append(same, regroup(large, 0)...)
+
+	groups := make([]DupeGroup, len(same))
+	for i, files := range same {
+		groups[i] = DupeGroup{Remove: files, Size: files[0].size}
+	}
+
+	// Biggest savings first
+	sort.Slice(groups, func(i, j int) bool {
+		wi := groups[i].Size * int64(len(groups[i].Remove)-1)
+		wj := groups[j].Size * int64(len(groups[j].Remove)-1)
+		if wi != wj {
+			return wi > wj
+		}
+		return groups[i].Remove[0].path < groups[j].Remove[0].path
+	})
+	return groups, nil
+}
+
+// chooseKeeper applies the keep rule to a group, moving the kept file out of Remove.
+// Files inside preferDir win over all others; copies already hardlinked to the keeper are dropped.
+func chooseKeeper(group *DupeGroup, rule, preferDir string) {
+	files := group.Remove
+	sort.Slice(files, func(i, j int) bool {
+		a, b := files[i], files[j]
+		if preferDir != "" {
+			ina, inb := delf.IsInside(a.path, preferDir), delf.IsInside(b.path, preferDir)
+			if ina != inb {
+				return ina
+			}
+		}
+		switch rule {
+		case "newest":
+			if !a.modTime.Equal(b.modTime) {
+				return a.modTime.After(b.modTime)
+			}
+		case "shortest":
+			if len(a.path) != len(b.path) {
+				return len(a.path) < len(b.path)
+			}
+		default: // oldest
+			if !a.modTime.Equal(b.modTime) {
+				return a.modTime.Before(b.modTime)
+			}
+		}
+		return a.path < b.path
+	})
+
+	group.Keep = files[0]
+	group.Remove = nil
+	for _, f := range files[1:] {
+		if os.SameFile(f.info, group.Keep.info) {
+			group.Already++
+			continue
+		}
+		group.Remove = append(group.Remove, f)
+	}
+}
+
+// hardlinkDeleter replaces each duplicate with a hardlink to the copy that is kept
+type hardlinkDeleter struct {
+	keepers map[string]string // duplicate path -> kept path
+}
+
+// Delete links the kept file next to the duplicate and renames it over the duplicate,
+// so the path never disappears
+func (d hardlinkDeleter) Delete(result delf.Result) error {
+	keep, ok := d.keepers[result.Path]
+	if !ok {
+		return fmt.Errorf("no kept copy recorded")
+	}
+	tmp := result.Path + ".delf-link"
+	if err := os.Link(keep, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, result.Path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (hardlinkDeleter) Verb() string    { return "Hardlinked" }
+func (hardlinkDeleter) Heading() string { return "Replacing duplicates with hardlinks..." }
+
+// dupeCheck re-checks both copies before each duplicate is disposed of, since
+// either may have been written to after hashing. A duplicate that changed is
+// left alone; once a kept copy has changed, the rest of its group is left alone.
+type dupeCheck struct {
+	delf.Deleter
+	keepers map[string]dupeFile // duplicate path -> kept file as it was compared
+	dupes   map[string]dupeFile // duplicate path -> duplicate as it was compared
+	changed map[string]bool     // kept paths found changed
+}
+
+// Delete passes the duplicate on to the inner deleter if neither copy changed
+func (d *dupeCheck) Delete(result delf.Result) error {
+	keep, ok := d.keepers[result.Path]
+	dupe, found := d.dupes[result.Path]
+	if !ok || !found {
+		return fmt.Errorf("no kept copy recorded")
+	}
+	if d.changed[keep.path] || fileChanged(keep) {
+		d.changed[keep.path] = true
+		return skipError{reason: "kept copy " + keep.path + " changed since it was compared"}
+	}
+	if fileChanged(dupe) {
+		return skipError{reason: "changed since it was compared"}
+	}
+	return d.Deleter.Delete(result)
+}
+
+// fileChanged reports whether a compared file is gone, replaced, or differs in size or mtime
+func fileChanged(f dupeFile) bool {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(info, f.info) || info.Size() != f.size || !info.ModTime().Equal(f.modTime)
+}
+
+// runDupesCommand implements `delf dupes [PATH]`
+func runDupesCommand(args []string) {
+	fs := flag.NewFlagSet("dupes", flag.ExitOnError)
+	keep := fs.String("keep", "oldest", "Which copy to keep: oldest, newest or shortest (path)")
+	keepIn := fs.String("keep-in", "", "Prefer keeping the copy inside DIR")
+	hardlink := fs.Bool("hardlink", false, "Replace removed copies with hardlinks to the kept one")
+	minSizeStr := fs.String("min-size", "1", "Ignore files smaller than SIZE")
+	fs.BoolVar(&opts.DryRun, "n", false, "Preview only, don't delete anything")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Preview only, don't delete anything")
+	fs.BoolVar(&opts.Force, "f", false, "Skip all confirmations (dangerous!)")
+	fs.BoolVar(&opts.Force, "force", false, "Skip all confirmations (dangerous!)")
+	fs.BoolVar(&opts.All, "a", false, "Disable auto-exclusion of common directories")
+	fs.BoolVar(&opts.NoAudit, "no-audit", false, "Don't record deletions in the audit log")
+	fs.IntVar(&opts.MaxDisplay, "max-display", 100, "Maximum groups to display")
+	fs.Usage = showDupesHelp
+	fs.Parse(args)
+
+	if *keep != "oldest" && *keep != "newest" && *keep != "shortest" {
+		fmt.Fprintf(console, "%s --keep must be oldest, newest or shortest\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+	minSize, err := delf.ParseSize(*minSizeStr)
+	if err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+	root, _ = filepath.Abs(root)
+	if *keepIn != "" {
+		*keepIn, _ = filepath.Abs(*keepIn)
+	}
+	opts.Path = root
+	opts.ShowSize = true
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("Searching for duplicates..."))
+	fmt.Fprintf(console, "%s %s\n", colors.Blue("Path:"), colors.Cyan(root))
+	fmt.Fprintf(console, "%s %s\n", colors.Blue("Keep:"), colors.Yellow(*keep))
+	if *keepIn != "" {
+		fmt.Fprintf(console, "%s %s\n", colors.Blue("Prefer:"), colors.Cyan(*keepIn))
+	}
+
+	groups, err := findDupes(root, minSize)
+	if err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+
+	// Pick keepers and turn the rest into ordinary results
+	var results []delf.Result
+	keepers := make(map[string]dupeFile)
+	dupes := make(map[string]dupeFile)
+	var reclaim int64
+	for i := range groups {
+		chooseKeeper(&groups[i], *keep, *keepIn)
+		for _, f := range groups[i].Remove {
+			results = append(results, delf.Result{Path: f.path, Category: classifier.Classify(f.path)})
+			keepers[f.path] = groups[i].Keep
+			dupes[f.path] = f
+			reclaim += f.size
+		}
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(console)
+		fmt.Fprintln(console, colors.Green(colors.Bold("No duplicate files found")))
+		os.Exit(0)
+	}
+
+	showDupeGroups(groups)
+
+	critical, warning, safe := countByCategory(results)
+	showMatchSummary(len(results), results, critical, warning, safe)
+	fmt.Fprintf(console, "%s %s in %d groups\n", colors.Bold("Reclaimable:"), colors.Yellow(formatSize(reclaim)), len(groups))
+
+	if critical > 0 && !isAdmin() {
+		showNoPermissionWarning(critical)
+		results = filterOutCritical(results)
+		if len(results) == 0 {
+			os.Exit(1)
+		}
+	}
+
+	var deleter delf.Deleter = delf.PermanentDeleter{}
+	if *hardlink {
+		links := make(map[string]string, len(keepers))
+		for path, keep := range keepers {
+			links[path] = keep.path
+		}
+		deleter = hardlinkDeleter{keepers: links}
+	}
+	deleter = &dupeCheck{Deleter: deleter, keepers: keepers, dupes: dupes, changed: make(map[string]bool)}
+	if opts.DryRun {
+		deleter = delf.DryRunDeleter{Inner: deleter}
+	}
+
+	if !opts.Force && !opts.DryRun && !confirmDeletion() {
+		fmt.Fprintln(console)
+		fmt.Fprintln(console, colors.Yellow("Operation cancelled"))
+		os.Exit(2)
+	}
+
+	var audit *auditLog
+	if !opts.NoAudit && !opts.DryRun {
+		audit, err = openAuditLog(auditPath(), false, false)
+		if err != nil {
+			fmt.Fprintf(console, "%s audit log unavailable: %v\n", colors.Yellow("Warning:"), err)
+		}
+	}
+
+	ctx, stop := withSignals()
+	deleted, failed, skipped, left := performDeletion(ctx, results, deleter, audit)
+	stop()
+	audit.close(deleted, failed, skipped, len(left), len(left) > 0)
+
+	showDeletionProgress(deleter.Verb(), deleted, failed, skipped)
+	if len(left) > 0 {
+		showInterrupted(left)
+		os.Exit(exitInterrupted)
+	}
+	if opts.DryRun {
+		showDryRunNotice()
+	}
+}
+
+// showDupeGroups lists each group with the kept copy first
+func showDupeGroups(groups []DupeGroup) {
+	fmt.Fprintln(console)
+	fmt.Fprintf(console, "%s\n", colors.Bold("Duplicates:"))
+
+	count := 0
+	for i, group := range groups {
+		if i >= opts.MaxDisplay {
+			fmt.Fprintf(console, "%s\n", colors.Yellow(fmt.Sprintf("  ... and %d more groups", len(groups)-i)))
+			break
+		}
+		if len(group.Remove) == 0 {
+			continue
+		}
+
+		fmt.Fprintln(console)
+		header := fmt.Sprintf("Group %d: %d copies of %s", i+1, len(group.Remove)+1+group.Already, formatSize(group.Size))
+		if group.Already > 0 {
+			header += fmt.Sprintf(" (%d already hardlinked)", group.Already)
+		}
+		fmt.Fprintln(console, colors.Bold(header))
+		fmt.Fprintf(console, "%s %s\n", colors.Green("  KEEP"), colors.Green(group.Keep.path))
+		for _, f := range group.Remove {
+			count++
+			showResult(f.path, count, classifier.Classify(f.path))
+		}
+	}
+}
+
+// showDupesHelp displays usage for `delf dupes`
+func showDupesHelp() {
+	fmt.Fprintln(console, colors.Bold("USAGE:"))
+	fmt.Fprintln(console, "    delf dupes [OPTIONS] [PATH]")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, "    Finds files with identical contents and deletes all but one copy per group.")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("OPTIONS:"))
+	fmt.Fprintf(console, "    %s   Which copy to keep: oldest (default), newest, shortest\n", colors.Cyan("--keep RULE"))
+	fmt.Fprintf(console, "    %s  Prefer keeping the copy inside DIR\n", colors.Cyan("--keep-in DIR"))
+	fmt.Fprintf(console, "    %s     Replace removed copies with hardlinks to the kept one\n", colors.Cyan("--hardlink"))
+	fmt.Fprintf(console, "    %s Ignore files smaller than SIZE (default: 1)\n", colors.Cyan("--min-size SIZE"))
+	fmt.Fprintf(console, "    %s  Preview only, don't delete anything\n", colors.Cyan("-n, --dry-run"))
+	fmt.Fprintf(console, "    %s    Skip all confirmations (dangerous!)\n", colors.Cyan("-f, --force"))
+	fmt.Fprintf(console, "    %s             Disable auto-exclusion of common directories\n", colors.Cyan("-a"))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// statDupe describes a file on disk the way findDupeCandidates does
+func statDupe(t *testing.T, path string) dupeFile {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dupeFile{path: path, size: info.Size(), modTime: info.ModTime(), info: info}
+}
+
+func TestDupeCheckKeeper(t *testing.T) {
+	out, recorder := recordRun(t)
+
+	root := t.TempDir()
+	keep := filepath.Join(root, "keep")
+	a := filepath.Join(root, "a")
+	b := filepath.Join(root, "b")
+	for _, path := range []string{keep, a, b} {
+		touchFile(t, path)
+	}
+	kept := statDupe(t, keep)
+	d := &dupeCheck{
+		Deleter: delf.PermanentDeleter{},
+		keepers: map[string]dupeFile{a: kept, b: kept},
+		dupes:   map[string]dupeFile{a: statDupe(t, a), b: statDupe(t, b)},
+		changed: make(map[string]bool),
+	}
+
+	if disposeOf(delf.Result{Path: a}, d, nil) != itemDeleted {
+		t.Fatalf("duplicate of an unchanged keeper was not deleted: %s", out)
+	}
+
+	// Touching the keeper after the comparison leaves the rest of the group
+	later := kept.modTime.Add(time.Hour)
+	if err := os.Chtimes(keep, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if disposeOf(delf.Result{Path: b}, d, nil) != itemSkipped {
+		t.Fatal("duplicate of a changed keeper was not skipped")
+	}
+	if _, err := os.Stat(b); err != nil {
+		t.Fatal("duplicate of a changed keeper was removed")
+	}
+	if skipped := recorder.Events(delf.EventSkipped); len(skipped) != 1 || skipped[0].Path != b {
+		t.Fatalf("skipped events = %v", skipped)
+	}
+
+	// Restoring the mtime does not bring the group back
+	if err := os.Chtimes(keep, kept.modTime, kept.modTime); err != nil {
+		t.Fatal(err)
+	}
+	if disposeOf(delf.Result{Path: b}, d, nil) != itemSkipped {
+		t.Fatal("group was resumed after its keeper changed")
+	}
+}
+
+func TestDupeCheckDuplicate(t *testing.T) {
+	_, recorder := recordRun(t)
+
+	root := t.TempDir()
+	keep := filepath.Join(root, "keep")
+	a := filepath.Join(root, "a")
+	b := filepath.Join(root, "b")
+	for _, path := range []string{keep, a, b} {
+		touchFile(t, path)
+	}
+	kept := statDupe(t, keep)
+	d := &dupeCheck{
+		Deleter: delf.PermanentDeleter{},
+		keepers: map[string]dupeFile{a: kept, b: kept},
+		dupes:   map[string]dupeFile{a: statDupe(t, a), b: statDupe(t, b)},
+		changed: make(map[string]bool),
+	}
+
+	// New contents written to a duplicate after hashing must survive
+	if err := os.WriteFile(a, []byte("unique contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if disposeOf(delf.Result{Path: a}, d, nil) != itemSkipped {
+		t.Fatal("changed duplicate was not skipped")
+	}
+	if _, err := os.Stat(a); err != nil {
+		t.Fatal("changed duplicate was removed")
+	}
+	if skipped := recorder.Events(delf.EventSkipped); len(skipped) != 1 || skipped[0].Path != a {
+		t.Fatalf("skipped events = %v", skipped)
+	}
+
+	// The rest of the group still goes
+	if disposeOf(delf.Result{Path: b}, d, nil) != itemDeleted {
+		t.Fatal("unchanged duplicate was not deleted")
+	}
+}
+
+func TestFileChanged(t *testing.T) {
+	root := t.TempDir()
+	keep := filepath.Join(root, "keep")
+	touchFile(t, keep)
+	kept := statDupe(t, keep)
+
+	if fileChanged(kept) {
+		t.Fatal("unchanged keeper reported as changed")
+	}
+
+	if err := os.WriteFile(keep, []byte("different contents"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(keep, kept.modTime, kept.modTime); err != nil {
+		t.Fatal(err)
+	}
+	if !fileChanged(kept) {
+		t.Fatal("resized keeper not reported")
+	}
+
+	// Replaced by another file with the same size and mtime
+	other := filepath.Join(root, "other")
+	if err := os.WriteFile(other, []byte(keep), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(other, kept.modTime, kept.modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(other, keep); err != nil {
+		t.Fatal(err)
+	}
+	if !fileChanged(kept) {
+		t.Fatal("replaced keeper not reported")
+	}
+
+	os.Remove(keep)
+	if !fileChanged(kept) {
+		t.Fatal("removed keeper not reported")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// sink receives search and deletion events; it is picked from --json and --quiet
+var sink delf.Sink = &terminalSink{}
+
+// console receives everything meant for people. With --json it is stderr, so
+// events are the only thing written to stdout.
+var console io.Writer = os.Stdout
+
+// newSink returns the sink for the output flags
+func newSink() delf.Sink {
+	switch {
+	case opts.JSON:
+		return delf.NewJSONSink(os.Stdout)
+	case opts.Quiet:
+		return quietSink{}
+	default:
+		return &terminalSink{}
+	}
+}
+
+// terminalSink shows events as the colored lines of the interactive flow
+// Entries that could not be read are summarized once the search ends.
+type terminalSink struct {
+	found     int
+	streaming bool // matches are listed as they are deleted instead
+}
+
+func (t *terminalSink) Event(e delf.Event) {
+	switch e.Kind {
+	case delf.EventResultFound:
+		if t.streaming {
+			return
+		}
+
+		// Display result in real-time (streaming)
+		t.found++
+		if t.found <= opts.MaxDisplay {
+			showResult(e.Result.Path, t.found, e.Result.Category)
+		} else if t.found == opts.MaxDisplay+1 {
+			fmt.Fprintf(console, "%s\n", colors.Yellow("  ... (more results, display limit reached)"))
+		}
+
+	case delf.EventSkipped:
+		// Search skips (auto-excluded directories) carry no Result; an empty
+		// search ends with a note about auto-exclusion instead
+		if e.Result.Path != "" {
+			fmt.Fprintf(console, "%s Skipped: %s %s\n",
+				colors.Yellow("!"),
+				e.Result.Path,
+				colors.Yellow(fmt.Sprintf("(%s)", e.Reason)))
+		}
+
+	case delf.EventDeleted:
+		showInUse(e.Result)
+		fmt.Fprintf(console, "%s %s: %s\n",
+			colors.Green("OK"),
+			e.Verb,
+			colors.Red(e.Result.Path))
+
+	case delf.EventFailed:
+		showInUse(e.Result)
+		fmt.Fprintf(console, "%s Failed: %s %s\n",
+			colors.Red("X"),
+			e.Result.Path,
+			colors.Yellow(fmt.Sprintf("(%s)", e.Err.Error())))
+	}
+}
+
+// showInUse notes that a result was still held open when it was removed
+func showInUse(result delf.Result) {
+	if len(result.Holders) > 0 {
+		fmt.Fprintf(console, "%s In use: %s %s\n",
+			colors.Yellow("!"),
+			result.Path,
+			colors.Yellow(fmt.Sprintf("(held by %s)", delf.FormatHolders(result.Holders))))
+	}
+}
+
+// quietSink drops per-item output, keeping only failures
+type quietSink struct{}
+
+func (quietSink) Event(e delf.Event) {
+	if e.Kind == delf.EventFailed {
+		fmt.Fprintf(console, "%s Failed: %s %s\n",
+			colors.Red("X"),
+			e.Result.Path,
+			colors.Yellow(fmt.Sprintf("(%s)", e.Err.Error())))
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// filter holds the parsed metadata filters; the zero value matches everything
+var filter delf.Filter
+
+// newSearchFilter parses the filter flags from opts
+func newSearchFilter(now time.Time) (delf.Filter, error) {
+	var f delf.Filter
+	var err error
+
+	// Age
+	f.TimeField = strings.ToLower(opts.TimeField)
+	if f.TimeField == "" {
+		f.TimeField = "mtime"
+	}
+	if !slices.Contains(delf.TimeFields, f.TimeField) {
+		return f, fmt.Errorf("--time-field must be one of %s", strings.Join(delf.TimeFields, ", "))
+	}
+	if opts.OlderThan != "" {
+		if f.OlderThan, err = delf.ParseAge(opts.OlderThan, now); err != nil {
+			return f, fmt.Errorf("--older-than: %w", err)
+		}
+	}
+	if opts.NewerThan != "" {
+		if f.NewerThan, err = delf.ParseAge(opts.NewerThan, now); err != nil {
+			return f, fmt.Errorf("--newer-than: %w", err)
+		}
+	}
+	if !f.OlderThan.IsZero() && !f.NewerThan.IsZero() && !f.NewerThan.Before(f.OlderThan) {
+		return f, fmt.Errorf("--newer-than %s and --older-than %s leave no time range", opts.NewerThan, opts.OlderThan)
+	}
+
+	// Size
+	if opts.LargerThan != "" || opts.SmallerThan != "" || opts.Size != "" {
+		size := delf.SizeRange{Max: -1, DirTotals: opts.DirSize}
+		if opts.LargerThan != "" {
+			n, err := delf.ParseSize(opts.LargerThan)
+			if err != nil {
+				return f, fmt.Errorf("--larger-than: %w", err)
+			}
+			size.Narrow(n+1, -1)
+		}
+		if opts.SmallerThan != "" {
+			n, err := delf.ParseSize(opts.SmallerThan)
+			if err != nil {
+				return f, fmt.Errorf("--smaller-than: %w", err)
+			}
+			// Max -1 would mean unbounded, and no size is below 0 anyway
+			if n == 0 {
+				return f, fmt.Errorf("--smaller-than 0 matches nothing")
+			}
+			size.Narrow(0, n-1)
+		}
+		if opts.Size != "" {
+			r, err := delf.ParseSizeRange(opts.Size)
+			if err != nil {
+				return f, fmt.Errorf("--size: %w", err)
+			}
+			size.Narrow(r.Min, r.Max)
+		}
+		if size.Empty() {
+			return f, fmt.Errorf("size filters leave no size range")
+		}
+		f.Size = &size
+	}
+
+	// Ownership and permissions
+	if f.Owner, err = newOwnership(); err != nil {
+		return f, err
+	}
+	if opts.Perm != "" {
+		perm, err := delf.ParsePerm(opts.Perm)
+		if err != nil {
+			return f, fmt.Errorf("--perm: %w", err)
+		}
+		f.Perm = &perm
+	}
+
+	// Content
+	if f.Content, err = newContentFilter(); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// retention holds the parsed retention flags; the zero value keeps nothing
+var retention delf.Retention
+
+// newRetention parses --keep-newest, --keep-oldest, --keep-daily, --keep-weekly,
+// --keep-monthly and --group-by from opts
+func newRetention() (delf.Retention, error) {
+	r := delf.Retention{
+		KeepNewest:  opts.KeepNewest,
+		KeepOldest:  opts.KeepOldest,
+		KeepDaily:   opts.KeepDaily,
+		KeepWeekly:  opts.KeepWeekly,
+		KeepMonthly: opts.KeepMonthly,
+		TimeField:   strings.ToLower(opts.TimeField),
+	}
+	if r.KeepNewest < 0 || r.KeepOldest < 0 || r.KeepDaily < 0 || r.KeepWeekly < 0 || r.KeepMonthly < 0 {
+		return r, fmt.Errorf("--keep-newest, --keep-oldest, --keep-daily, --keep-weekly and --keep-monthly cannot be negative")
+	}
+	if opts.GroupBy != "" {
+		if !r.Active() {
+			return r, fmt.Errorf("--group-by needs --keep-newest, --keep-oldest, --keep-daily, --keep-weekly or --keep-monthly")
+		}
+		re, err := regexp.Compile(opts.GroupBy)
+		if err != nil {
+			return r, fmt.Errorf("--group-by: %w", err)
+		}
+		r.GroupBy = re
+	}
+	return r, nil
+}
+
+// newOwnership resolves --user/--group/--uid/--gid/--nouser, or returns nil when none is set
+func newOwnership() (*delf.Ownership, error) {
+	o := delf.Ownership{UID: opts.UID, GID: opts.GID, NoUser: opts.NoUser}
+
+	if opts.User != "" {
+		uid, err := delf.LookupUID(opts.User)
+		if err != nil {
+			return nil, fmt.Errorf("--user: %w", err)
+		}
+		if o.UID >= 0 && o.UID != uid {
+			return nil, fmt.Errorf("--user %s and --uid %d refer to different users", opts.User, o.UID)
+		}
+		o.UID = uid
+	}
+	if opts.Group != "" {
+		gid, err := delf.LookupGID(opts.Group)
+		if err != nil {
+			return nil, fmt.Errorf("--group: %w", err)
+		}
+		if o.GID >= 0 && o.GID != gid {
+			return nil, fmt.Errorf("--group %s and --gid %d refer to different groups", opts.Group, o.GID)
+		}
+		o.GID = gid
+	}
+
+	if o.UID < 0 && o.GID < 0 && !o.NoUser {
+		return nil, nil
+	}
+	if !delf.OwnershipSupported {
+		return nil, fmt.Errorf("--user, --group, --uid, --gid and --nouser are not supported on this platform")
+	}
+	return &o, nil
+}
+
+// newContentFilter parses --mime, --kind, --executable and --contains from opts
+func newContentFilter() (delf.ContentFilter, error) {
+	f := delf.ContentFilter{Kind: strings.ToLower(opts.Kind), Executable: opts.Executable}
+
+	for _, pattern := range strings.Split(opts.Mime, ",") {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return f, fmt.Errorf("--mime: invalid pattern %s", pattern)
+		}
+		f.Mime = append(f.Mime, pattern)
+	}
+	if f.Kind != "" && !slices.Contains(delf.ContentKinds, f.Kind) {
+		return f, fmt.Errorf("--kind must be one of %s", strings.Join(delf.ContentKinds, ", "))
+	}
+	if opts.Contains != "" {
+		re, err := regexp.Compile(opts.Contains)
+		if err != nil {
+			return f, fmt.Errorf("--contains: %w", err)
+		}
+		f.Contains = re
+	}
+	if f.Active() && opts.EmptyDirs {
+		return f, fmt.Errorf("--mime, --kind, --executable and --contains only match files, not --empty-dirs")
+	}
+	return f, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearchFilterSize(t *testing.T) {
+	tests := []struct {
+		name             string
+		larger, smaller  string
+		size             string
+		wantMin, wantMax int64
+		wantErr          bool
+	}{
+		{name: "smaller than", smaller: "1K", wantMin: 0, wantMax: 1023},
+		{name: "smaller than one byte", smaller: "1", wantMin: 0, wantMax: 0},
+		{name: "smaller than zero", smaller: "0", wantErr: true},
+		{name: "smaller than zero bytes", smaller: "0B", wantErr: true},
+		{name: "larger than", larger: "1K", wantMin: 1025, wantMax: -1},
+		{name: "larger and smaller", larger: "1K", smaller: "2K", wantMin: 1025, wantMax: 2047},
+		{name: "no range left", larger: "2K", smaller: "1K", wantErr: true},
+		{name: "size range", size: "1K..2K", wantMin: 1024, wantMax: 2048},
+		{name: "size range up to zero", size: "..0", wantMin: 0, wantMax: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recordRun(t)
+			opts.LargerThan, opts.SmallerThan, opts.Size = tt.larger, tt.smaller, tt.size
+
+			f, err := newSearchFilter(time.Now())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("filter accepted, range %d..%d", f.Size.Min, f.Size.Max)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Size.Min != tt.wantMin || f.Size.Max != tt.wantMax {
+				t.Fatalf("range %d..%d, want %d..%d", f.Size.Min, f.Size.Max, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
@@ -5,9 +5,7 @@ go 1.21
 require (
 	github.com/fatih/color v1.16.0
 	github.com/mattn/go-isatty v0.0.20
+	golang.org/x/sys v0.14.0
 )
 
-require (
-	github.com/mattn/go-colorable v0.1.13 // indirect
-	golang.org/x/sys v0.14.0 // indirect
-)
+require github.com/mattn/go-colorable v0.1.13 // indirect
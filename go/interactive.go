@@ -6,13 +6,26 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
 )
 
 var reader = bufio.NewReader(os.Stdin)
 
+// promptFromTerminal reads answers from the terminal because stdin carries a path list.
+// Without a terminal every answer is empty, so nothing is confirmed by accident.
+func promptFromTerminal() {
+	tty, err := os.Open(ttyPath)
+	if err != nil {
+		reader = bufio.NewReader(strings.NewReader(""))
+		return
+	}
+	reader = bufio.NewReader(tty)
+}
+
 // readLine reads a line of input from stdin
 func readLine(prompt string) string {
-	fmt.Print(prompt)
+	fmt.Fprint(console, prompt)
 	input, _ := reader.ReadString('\n')
 	return strings.TrimSpace(input)
 }
@@ -21,11 +34,11 @@ func readLine(prompt string) string {
 func getSearchPath() string {
 	cwd, _ := os.Getwd()
 
-	fmt.Println("Enter path to search (default: current directory)")
+	fmt.Fprintln(console, "Enter path to search (default: current directory)")
 	userPath := readLine(colors.Cyan("> "))
 
 	if userPath == "" {
-		fmt.Printf("%s %s\n", colors.Blue("Searching in:"), colors.Cyan(cwd))
+		fmt.Fprintf(console, "%s %s\n", colors.Blue("Searching in:"), colors.Cyan(cwd))
 		return "."
 	}
 
@@ -43,22 +56,22 @@ func getSearchPath() string {
 	// Validate path exists
 	info, err := os.Stat(userPath)
 	if err != nil || !info.IsDir() {
-		fmt.Printf("%s Directory '%s' does not exist\n", colors.Red("ERROR:"), userPath)
+		fmt.Fprintf(console, "%s Directory '%s' does not exist\n", colors.Red("ERROR:"), userPath)
 		return ""
 	}
 
-	fmt.Printf("%s %s\n", colors.Blue("Searching in:"), colors.Cyan(userPath))
-	fmt.Println()
+	fmt.Fprintf(console, "%s %s\n", colors.Blue("Searching in:"), colors.Cyan(userPath))
+	fmt.Fprintln(console)
 	return userPath
 }
 
 // getPattern prompts for and returns the search pattern
 func getPattern() string {
-	fmt.Println("Enter file/folder name or pattern to delete")
+	fmt.Fprintln(console, "Enter file/folder name or pattern to delete")
 	pattern := readLine(colors.Cyan("> "))
 
 	if pattern == "" {
-		fmt.Printf("%s Pattern cannot be empty\n", colors.Red("ERROR:"))
+		fmt.Fprintf(console, "%s Pattern cannot be empty\n", colors.Red("ERROR:"))
 		return ""
 	}
 
@@ -67,10 +80,10 @@ func getPattern() string {
 
 // getExclusions prompts for exclusion patterns
 func getExclusions() []string {
-	fmt.Println()
-	fmt.Println(colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
-	fmt.Printf("%s (comma-separated, or press Enter to skip):\n", colors.Cyan("Enter exclusion patterns"))
-	fmt.Printf("%s *\\important\\*, *.txt, *\\backup\\*\n", colors.Yellow("Examples:"))
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintf(console, "%s (comma-separated, or press Enter to skip):\n", colors.Cyan("Enter exclusion patterns"))
+	fmt.Fprintf(console, "%s *\\important\\*, *.txt, *\\backup\\*\n", colors.Yellow("Examples:"))
 
 	input := readLine(colors.Cyan("> "))
 
@@ -88,8 +101,8 @@ func getExclusions() []string {
 
 // confirmDeletion asks for final confirmation before deletion
 func confirmDeletion() bool {
-	fmt.Println()
-	fmt.Println(colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
 	confirmation := readLine(colors.BoldRed("Proceed with deletion? (y/N) "))
 
 	return strings.ToLower(confirmation) == "y"
@@ -98,7 +111,7 @@ func confirmDeletion() bool {
 // confirmCriticalDeletion asks for extra confirmation for system files
 func confirmCriticalDeletion(criticalCount int) bool {
 	showCriticalWarning(criticalCount)
-	fmt.Printf("%s %s\n", colors.BoldRed("To proceed, type exactly:"), colors.Yellow("YES DELETE SYSTEM FILES"))
+	fmt.Fprintf(console, "%s %s\n", colors.BoldRed("To proceed, type exactly:"), colors.Yellow("YES DELETE SYSTEM FILES"))
 
 	confirmation := readLine(colors.Cyan("> "))
 
@@ -131,27 +144,65 @@ func runInteractiveMode() {
 
 // executeDeletionFlow handles the complete deletion workflow
 func executeDeletionFlow() {
-	// Search
-	results, _ := search(opts.Pattern, opts.Path)
+	// Forced and JSON runs delete matches as they are found
+	if useStreaming() {
+		streamDeletion()
+		return
+	}
+
+	// Search (Ctrl-C stops it without deleting anything)
+	ctx, stop := withSignals()
+	results, err := search(ctx, opts.Pattern, opts.Path)
+	interrupted := ctx.Err() != nil
+	stop()
+	if interrupted {
+		fmt.Fprintln(console)
+		fmt.Fprintf(console, "%s search stopped after %d matches, nothing was deleted\n", colors.Yellow(colors.Bold("Interrupted:")), len(results))
+		os.Exit(exitInterrupted)
+	}
+	if err != nil {
+		fmt.Fprintln(console)
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		if opts.Strict {
+			fmt.Fprintln(console, colors.Yellow("Nothing was deleted (--strict stops at the first unreadable entry)"))
+		}
+		os.Exit(1)
+	}
 
 	// Check for matches
 	if len(results) == 0 {
-		fmt.Println()
-		fmt.Printf("%s for pattern: %s\n",
-			colors.Yellow(colors.Bold("No matches found")),
-			colors.Cyan(opts.Pattern))
-		if !opts.All {
-			fmt.Printf("%s Auto-exclusions are enabled. Use %s flag to disable.\n",
-				colors.Yellow("Note:"),
-				colors.Cyan("-a"))
-		}
+		showNoMatches()
 		os.Exit(1)
 	}
 
-	// Show summary by category
+	// Rank by size and let the user pick when --top is given
+	if opts.Top > 0 {
+		results = pickFromUsage(buildUsageReport(results), opts.Top)
+	}
+
+	processResults(results)
+}
+
+// processResults takes matched results through classification, summary,
+// exclusions, preview, confirmation and deletion
+func processResults(results []delf.Result) {
+	// Classify against git so tracked files show up as warnings
+	if opts.GitSafe {
+		var err error
+		results, err = delf.AnnotateGitStatus(results)
+		if err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			os.Exit(1)
+		}
+	}
+
+	// A matched directory takes everything below it, so act on roots only
 	total := len(results)
+	results = delf.CollapseNested(results)
+
+	// Show summary by category
 	critical, warning, safe := countByCategory(results)
-	showMatchSummary(total, critical, warning, safe)
+	showMatchSummary(total, results, critical, warning, safe)
 
 	// Handle critical files for non-admin
 	if critical > 0 && !isAdmin() {
@@ -159,19 +210,45 @@ func executeDeletionFlow() {
 		results = filterOutCritical(results)
 
 		if len(results) == 0 {
-			fmt.Println(colors.Yellow("All matched files are system files. Nothing can be deleted without Administrator."))
+			fmt.Fprintln(console, colors.Yellow("All matched files are system files. Nothing can be deleted without Administrator."))
 			os.Exit(1)
 		}
 
-		fmt.Printf("%s\n", colors.Green(fmt.Sprintf("Proceeding with %d safe/warning-level files only...", len(results))))
+		fmt.Fprintf(console, "%s\n", colors.Green(fmt.Sprintf("Proceeding with %d safe/warning-level files only...", len(results))))
+	}
+
+	// Keep tracked files out of git-safe runs
+	if opts.GitSafe {
+		var protected []delf.Result
+		results, protected = delf.FilterGitProtected(results)
+		showGitProtectedFiles(protected)
+
+		if len(results) == 0 {
+			fmt.Fprintln(console)
+			fmt.Fprintln(console, colors.Green(colors.Bold("All matches are tracked by git. Nothing to delete.")))
+			os.Exit(0)
+		}
+	}
+
+	// Retention rules take the matches to keep out of the delete set
+	if retention.Active() {
+		var kept []delf.Result
+		results, kept = retention.Apply(results)
+		showRetainedFiles(kept)
+
+		if len(results) == 0 {
+			fmt.Fprintln(console)
+			fmt.Fprintln(console, colors.Green(colors.Bold("Retention keeps every match. Nothing to delete.")))
+			os.Exit(0)
+		}
 	}
 
 	// Show size if requested
 	if opts.ShowSize {
-		fmt.Println()
-		fmt.Printf("%s\n", colors.Blue("Calculating total size..."))
-		totalSize := getTotalSize(results)
-		fmt.Printf("%s %s\n", colors.Bold("Total size:"), colors.Yellow(formatSize(totalSize)))
+		fmt.Fprintln(console)
+		fmt.Fprintf(console, "%s\n", colors.Blue("Calculating total size..."))
+		totalSize := delf.TotalSize(results)
+		fmt.Fprintf(console, "%s %s\n", colors.Bold("Total size:"), colors.Yellow(formatSize(totalSize)))
 	}
 
 	// Ask for exclusions (unless Force mode)
@@ -186,18 +263,21 @@ func executeDeletionFlow() {
 
 	// Check if anything left to delete
 	if len(results) == 0 {
-		fmt.Println()
-		fmt.Println(colors.Green(colors.Bold("All files excluded. Nothing to delete.")))
+		fmt.Fprintln(console)
+		fmt.Fprintln(console, colors.Green(colors.Bold("All files excluded. Nothing to delete.")))
 		os.Exit(0)
 	}
 
+	// Find files still held open by running processes
+	results, _ = delf.AnnotateOpenFiles(results)
+
 	// Preview deletion
 	previewDeletion(results, 10)
 
 	// Show size again after exclusions
 	if opts.ShowSize {
-		totalSize := getTotalSize(results)
-		fmt.Printf("%s %s\n", colors.Bold("Total size:"), colors.Yellow(formatSize(totalSize)))
+		totalSize := delf.TotalSize(results)
+		fmt.Fprintf(console, "%s %s\n", colors.Bold("Total size:"), colors.Yellow(formatSize(totalSize)))
 	}
 
 	// Dry-run mode
@@ -206,30 +286,58 @@ func executeDeletionFlow() {
 		os.Exit(0)
 	}
 
+	// Pick how matches are disposed of
+	deleter, err := newDeleter()
+	if err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+
+	// Shredding is best-effort on some storage
+	if opts.Shred > 0 {
+		showShredWarnings(delf.ShredWarnings(results), opts.Shred)
+	}
+
 	// Final confirmation
 	if !opts.Force {
 		// Extra confirmation for critical files (admin only)
 		critical, _, _ = countByCategory(results)
 		if isAdmin() && critical > 0 {
 			if !confirmCriticalDeletion(critical) {
-				fmt.Println()
-				fmt.Println(colors.Green("Operation cancelled. System is safe."))
+				fmt.Fprintln(console)
+				fmt.Fprintln(console, colors.Green("Operation cancelled. System is safe."))
 				os.Exit(2)
 			}
 		}
 
 		if !confirmDeletion() {
-			fmt.Println()
-			fmt.Println(colors.Yellow("Operation cancelled"))
+			fmt.Fprintln(console)
+			fmt.Fprintln(console, colors.Yellow("Operation cancelled"))
 			os.Exit(2)
 		}
 	}
 
-	// Perform deletion
-	deleted, failed := performDeletion(results, true)
+	// Record the run in the audit log
+	var audit *auditLog
+	if !opts.NoAudit {
+		audit, err = openAuditLog(auditPath(), opts.AuditHash, opts.SysLog)
+		if err != nil {
+			fmt.Fprintf(console, "%s audit log unavailable: %v\n", colors.Yellow("Warning:"), err)
+		}
+	}
+
+	// Perform deletion; Ctrl-C lets the current item finish, then stops
+	ctx, stop := withSignals()
+	deleted, failed, skipped, left := performDeletion(ctx, results, deleter, audit)
+	stop()
+	audit.close(deleted, failed, skipped, len(left), len(left) > 0)
 
 	// Show final summary
-	showDeletionProgress(deleted, failed)
+	showDeletionProgress(deleter.Verb(), deleted, failed, skipped)
+	if len(left) > 0 {
+		showInterrupted(left)
+		os.Exit(exitInterrupted)
+	}
 }
 
 // getTime returns current time in seconds
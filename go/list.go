@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// runListMode writes the paths delf would delete to stdout, one per line or
+// NUL-terminated with --print0, and nothing else: no headers, colors or
+// prompts. Critical system paths are never listed, matched directories are
+// listed once without their contents, and problems go to stderr.
+func runListMode() {
+	searchOpts := searchOptions(opts.Pattern, opts.Path)
+	searchOpts.Prune = true
+	searchOpts.Sink = delf.Discard
+	searcher := delf.NewSearcher(searchOpts)
+
+	out := bufio.NewWriter(os.Stdout)
+	sep := byte('\n')
+	if opts.Print0 {
+		sep = 0
+	}
+	listed := 0
+	list := func(result delf.Result) {
+		if result.Category == delf.CategoryCritical {
+			return
+		}
+		out.WriteString(result.Path)
+		out.WriteByte(sep)
+		listed++
+	}
+
+	ctx, stop := withSignals()
+	if opts.GitSafe || retention.Active() {
+		// Git status and retention need the whole set at once
+		var results []delf.Result
+		for result := range searcher.Search(ctx) {
+			results = append(results, result)
+		}
+		if opts.GitSafe {
+			annotated, err := delf.AnnotateGitStatus(results)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "delf: %v\n", err)
+				os.Exit(1)
+			}
+			results, _ = delf.FilterGitProtected(annotated)
+		}
+		results, _ = retention.Apply(results)
+		for _, result := range results {
+			list(result)
+		}
+	} else {
+		for result := range searcher.Search(ctx) {
+			list(result)
+		}
+	}
+	interrupted := ctx.Err() != nil
+	stop()
+	out.Flush()
+
+	if errs := searcher.Errors(); len(errs) > 0 && !opts.Strict {
+		fmt.Fprintf(os.Stderr, "delf: skipped %d entries that could not be read or checked (first: %v)\n", len(errs), errs[0])
+	}
+	switch err := searcher.Err(); {
+	case interrupted:
+		os.Exit(exitInterrupted)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "delf: %v\n", err)
+		os.Exit(1)
+	case listed == 0:
+		os.Exit(1)
+	}
+}
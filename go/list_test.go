@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// listRun runs runListMode over root and returns what it printed, split on sep
+func listRun(t *testing.T, root string, sep string) []string {
+	t.Helper()
+	out, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	runListMode()
+	os.Stdout = stdout
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(data), sep) {
+		t.Fatalf("output %q does not end with the separator", data)
+	}
+	var got []string
+	for _, path := range strings.Split(strings.TrimSuffix(string(data), sep), sep) {
+		rel, _ := filepath.Rel(root, path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestListMode(t *testing.T) {
+	for _, print0 := range []bool{false, true} {
+		name, sep := "newlines", "\n"
+		if print0 {
+			name, sep = "print0", "\x00"
+		}
+		t.Run(name, func(t *testing.T) {
+			recordRun(t)
+			saved := classifier
+			defer func() { classifier = saved }()
+
+			root := t.TempDir()
+			for _, path := range []string{"a.log", "logs/b.log", "old.log/c.log", "old.log/deeper/d.log", "sys/e.log", "keep.txt"} {
+				touchFile(t, filepath.Join(root, path))
+			}
+			classifier = &delf.Classifier{Critical: []string{filepath.Join(root, "sys")}}
+			opts.Pattern, opts.Path, opts.List, opts.Print0 = "*.log", root, true, print0
+
+			// sys/e.log is critical; old.log is listed once without its contents
+			want := []string{"a.log", "logs/b.log", "old.log"}
+			if got := listRun(t, root, sep); !reflect.DeepEqual(got, want) {
+				t.Fatalf("listed %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -1,28 +1,82 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
 )
 
 const Version = "2.0.0"
 
+// exitInterrupted is the exit status when Ctrl-C or SIGTERM stops a search or deletion
+const exitInterrupted = 130
+
 // Options holds the command-line options
 type Options struct {
-	Pattern    string
-	Path       string
-	DryRun     bool
-	Force      bool
-	IgnoreCase bool
-	Type       string
-	All        bool
-	ShowSize   bool
-	OlderThan  int
-	LargerThan string
-	EmptyDirs  bool
-	MaxDisplay int
-	Help       bool
+	Pattern     string
+	Path        string
+	Roots       []string
+	DryRun      bool
+	Force       bool
+	IgnoreCase  bool
+	Type        string
+	All         bool
+	ShowSize    bool
+	OlderThan   string
+	NewerThan   string
+	TimeField   string
+	LargerThan  string
+	SmallerThan string
+	Size        string
+	DirSize     bool
+	User        string
+	Group       string
+	UID         int
+	GID         int
+	NoUser      bool
+	Perm        string
+	Mime        string
+	Kind        string
+	Executable  bool
+	Contains    string
+	MaxDepth    int
+	MinDepth    int
+	Prune       bool
+	Limit       int
+	Strict      bool
+	Stdin       bool
+	FromFile    string
+	Null        bool
+	EmptyDirs   bool
+	MaxDisplay  int
+	JSON        bool
+	Quiet       bool
+	List        bool
+	Print0      bool
+	Top         int
+	KeepNewest  int
+	KeepOldest  int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	GroupBy     string
+	GitSafe     bool
+	SkipBusy    bool
+	Shred       int
+	Trash       bool
+	Archive     string
+	MoveTo      string
+	NoAudit     bool
+	AuditLog    string
+	AuditHash   bool
+	SysLog      bool
+	Help        bool
 }
 
 var opts Options
@@ -31,6 +85,28 @@ func main() {
 	// Initialize colors
 	initColors()
 
+	// Subcommands take the first argument; "delf -- log" or "delf -p log"
+	// searches for a pattern with a subcommand's name instead
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "log":
+			runLogCommand(os.Args[2:])
+			return
+		case "restore":
+			runRestoreCommand(os.Args[2:])
+			return
+		case "dupes":
+			runDupesCommand(os.Args[2:])
+			return
+		case "du":
+			runDuCommand(os.Args[2:])
+			return
+		case "run":
+			runRunCommand(os.Args[2:])
+			return
+		}
+	}
+
 	// Parse command-line arguments
 	parseArgs()
 
@@ -40,14 +116,38 @@ func main() {
 		os.Exit(0)
 	}
 
-	// If pattern provided (or empty dirs mode), run direct; otherwise interactive mode
-	if opts.Pattern != "" || opts.EmptyDirs {
+	// Print-only mode for pipelines
+	if opts.List {
+		runListMode()
+		return
+	}
+
+	// Route events to the chosen output
+	if opts.JSON {
+		console = os.Stderr
+	}
+	sink = newSink()
+
+	// If pattern provided (or empty dirs mode, or a path list), run direct; otherwise interactive mode
+	if opts.Pattern != "" || opts.EmptyDirs || pathList != nil {
 		executeDeletionFlow()
 	} else {
 		runInteractiveMode()
 	}
 }
 
+// withSignals returns a context that SIGINT and SIGTERM cancel instead of killing
+// the process. Only the first signal is caught: default handling is restored as
+// soon as ctx is cancelled, so a second Ctrl-C ends a run that is slow to stop.
+func withSignals() (ctx context.Context, stop context.CancelFunc) {
+	ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
 func parseArgs() {
 	// Help
 	flag.BoolVar(&opts.Help, "h", false, "Show help message")
@@ -64,6 +164,10 @@ func parseArgs() {
 	// Case-insensitive
 	flag.BoolVar(&opts.IgnoreCase, "i", false, "Case-insensitive pattern matching")
 
+	// Explicit pattern, for patterns that are also subcommand names
+	flag.StringVar(&opts.Pattern, "p", "", "Pattern to match; every positional argument is then a path")
+	flag.StringVar(&opts.Pattern, "pattern", "", "Pattern to match; every positional argument is then a path")
+
 	// Type filter
 	flag.StringVar(&opts.Type, "t", "", "Filter by type: 'f' for files, 'd' for directories")
 
@@ -74,10 +178,45 @@ func parseArgs() {
 	flag.BoolVar(&opts.ShowSize, "show-size", false, "Display total size of matched files")
 
 	// Age filter
-	flag.IntVar(&opts.OlderThan, "older-than", 0, "Only match files older than N days")
+	flag.StringVar(&opts.OlderThan, "older-than", "", "Only match entries older than AGE (30, 2h, 3w, 6mo) or DATE")
+	flag.StringVar(&opts.NewerThan, "newer-than", "", "Only match entries newer than AGE or DATE")
+	flag.StringVar(&opts.TimeField, "time-field", "mtime", "Timestamp for age filters: mtime, atime, ctime or btime")
 
 	// Size filter
-	flag.StringVar(&opts.LargerThan, "larger-than", "", "Only match files larger than SIZE (K,M,G)")
+	flag.StringVar(&opts.LargerThan, "larger-than", "", "Only match files larger than SIZE (1.5G, 500MB, 10KiB)")
+	flag.StringVar(&opts.SmallerThan, "smaller-than", "", "Only match files smaller than SIZE")
+	flag.StringVar(&opts.Size, "size", "", "Only match files with a size in MIN..MAX (either end optional)")
+	flag.BoolVar(&opts.DirSize, "dir-size", false, "Apply size filters to directory totals too")
+
+	// Ownership and permission filters
+	flag.StringVar(&opts.User, "user", "", "Only match entries owned by USER")
+	flag.StringVar(&opts.Group, "group", "", "Only match entries owned by GROUP")
+	flag.IntVar(&opts.UID, "uid", -1, "Only match entries owned by this numeric UID")
+	flag.IntVar(&opts.GID, "gid", -1, "Only match entries owned by this numeric GID")
+	flag.BoolVar(&opts.NoUser, "nouser", false, "Only match entries whose owner has no account")
+	flag.StringVar(&opts.Perm, "perm", "", "Only match permission MODE (644 exact, -o+w all bits, /111 any bit)")
+
+	// Content filters
+	flag.StringVar(&opts.Mime, "mime", "", "Only match files whose sniffed MIME type matches (image/*, comma-separated)")
+	flag.StringVar(&opts.Kind, "kind", "", "Only match files of a kind: text, binary, archive or media")
+	flag.BoolVar(&opts.Executable, "executable", false, "Only match executable files")
+	flag.StringVar(&opts.Contains, "contains", "", "Only match text files with a line matching REGEX")
+
+	// Depth and breadth controls
+	flag.IntVar(&opts.MaxDepth, "max-depth", 0, "Descend at most N levels below PATH")
+	flag.IntVar(&opts.MinDepth, "min-depth", 0, "Only match entries at least N levels below PATH")
+	flag.BoolVar(&opts.Prune, "prune", false, "Don't descend into matched directories")
+	flag.IntVar(&opts.Limit, "limit", 0, "Stop searching after N results")
+	flag.BoolVar(&opts.Strict, "strict", false, "Fail when a directory or file cannot be read")
+
+	// More than one search root
+	flag.Var(rootsFlag{&opts.Roots}, "root", "Also search DIR (repeatable)")
+
+	// Path lists instead of a search
+	flag.BoolVar(&opts.Stdin, "stdin", false, "Read the paths to delete from stdin instead of searching")
+	flag.StringVar(&opts.FromFile, "from-file", "", "Read the paths to delete from LIST (- for stdin) instead of searching")
+	flag.BoolVar(&opts.Null, "0", false, "Paths in the list are NUL-separated (find -print0)")
+	flag.BoolVar(&opts.Null, "null", false, "Paths in the list are NUL-separated (find -print0)")
 
 	// Empty dirs
 	flag.BoolVar(&opts.EmptyDirs, "empty-dirs", false, "Find and delete empty directories only")
@@ -85,6 +224,45 @@ func parseArgs() {
 	// Max display
 	flag.IntVar(&opts.MaxDisplay, "max-display", 100, "Maximum results to display")
 
+	// Output format
+	flag.BoolVar(&opts.JSON, "json", false, "Write results and outcomes to stdout as JSON lines")
+	flag.BoolVar(&opts.Quiet, "q", false, "Don't list matches or deletions, only summaries and failures")
+	flag.BoolVar(&opts.Quiet, "quiet", false, "Don't list matches or deletions, only summaries and failures")
+	flag.BoolVar(&opts.List, "list", false, "Only print the paths that would be deleted, one per line")
+	flag.BoolVar(&opts.Print0, "print0", false, "Like --list, but end each path with a NUL byte")
+
+	// Largest matches
+	flag.IntVar(&opts.Top, "top", 0, "Rank matches by size, show the N largest and pick what to delete")
+
+	// Retention
+	flag.IntVar(&opts.KeepNewest, "keep-newest", 0, "Never delete the N most recent matches")
+	flag.IntVar(&opts.KeepOldest, "keep-oldest", 0, "Never delete the N oldest matches")
+	flag.IntVar(&opts.KeepDaily, "keep-daily", 0, "Keep the newest match of each of the last N days")
+	flag.IntVar(&opts.KeepWeekly, "keep-weekly", 0, "Keep the newest match of each of the last N weeks")
+	flag.IntVar(&opts.KeepMonthly, "keep-monthly", 0, "Keep the newest match of each of the last N months")
+	flag.StringVar(&opts.GroupBy, "group-by", "", "Apply the keep rules per group of paths with the same REGEX captures")
+
+	// Git-aware safety
+	flag.BoolVar(&opts.GitSafe, "git-safe", false, "Inside git repos, only delete ignored and untracked files")
+
+	// Busy files
+	flag.BoolVar(&opts.SkipBusy, "skip-busy", false, "Skip files that are open by a running process")
+
+	// Secure overwrite
+	flag.Var(shredFlag{&opts.Shred}, "shred", "Overwrite file contents before deleting (optional =PASSES)")
+	flag.Var(shredPassesFlag{&opts.Shred}, "shred-passes", "Shred with N overwrite passes")
+
+	// Other deletion strategies
+	flag.BoolVar(&opts.Trash, "trash", false, "Move matches to the trash instead of deleting")
+	flag.StringVar(&opts.Archive, "archive", "", "Archive matches to FILE (.tar, .tar.gz, .tar.zst, .tar.xz, .zip) before deleting")
+	flag.StringVar(&opts.MoveTo, "move-to", "", "Move matches into DIR instead of deleting")
+
+	// Audit log
+	flag.BoolVar(&opts.NoAudit, "no-audit", false, "Don't record deletions in the audit log")
+	flag.StringVar(&opts.AuditLog, "audit-log", "", "Audit log file (default: $XDG_STATE_HOME/delf/audit.log)")
+	flag.BoolVar(&opts.AuditHash, "audit-hash", false, "Record the SHA-256 of each deleted file")
+	flag.BoolVar(&opts.SysLog, "syslog", false, "Also forward audit records to syslog/journald")
+
 	// Custom usage
 	flag.Usage = func() {
 		showHelp()
@@ -94,18 +272,125 @@ func parseArgs() {
 
 	// Get positional arguments
 	args := flag.Args()
+	if opts.Pattern == "" && len(args) >= 1 {
+		opts.Pattern, args = args[0], args[1:]
+
+		// --shred is a switch, so "--shred 5 secrets" would search for "5"
+		if opts.Shred > 0 && shredCountAsPattern(os.Args[1:], opts.Pattern) {
+			fmt.Fprintf(console, "%s --shred takes its passes as --shred=%s or --shred-passes %s; to search for %q, use -p %s\n",
+				colors.Red("ERROR:"), opts.Pattern, opts.Pattern, opts.Pattern, opts.Pattern)
+			os.Exit(1)
+		}
+	}
 	if len(args) >= 1 {
-		opts.Pattern = args[0]
+		opts.Roots = append(append([]string{}, args...), opts.Roots...)
 	}
-	if len(args) >= 2 {
-		opts.Path = args[1]
+	if len(opts.Roots) > 0 {
+		opts.Path = opts.Roots[0]
 	} else {
 		opts.Path = "."
 	}
 
 	// Validate type flag
 	if opts.Type != "" && opts.Type != "f" && opts.Type != "d" {
-		fmt.Printf("%s Type must be 'f' (file) or 'd' (directory)\n", colors.Red("ERROR:"))
+		fmt.Fprintf(console, "%s Type must be 'f' (file) or 'd' (directory)\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+
+	// Path lists
+	if opts.FromFile == "-" {
+		opts.Stdin, opts.FromFile = true, ""
+	}
+	if opts.Stdin && opts.FromFile != "" {
+		fmt.Fprintf(console, "%s --stdin and --from-file cannot be combined\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+	if opts.Null && !opts.Stdin && opts.FromFile == "" {
+		fmt.Fprintf(console, "%s -0 needs --stdin or --from-file\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+	if opts.EmptyDirs && (opts.Stdin || opts.FromFile != "") {
+		fmt.Fprintf(console, "%s --empty-dirs cannot be combined with a path list\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+	if len(opts.Roots) > 1 && (opts.Stdin || opts.FromFile != "") {
+		fmt.Fprintf(console, "%s a path list cannot be combined with several search paths\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+	if opts.FromFile != "" {
+		f, err := os.Open(opts.FromFile)
+		if err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			os.Exit(1)
+		}
+		pathList = f
+	} else if opts.Stdin {
+		pathList = os.Stdin
+		promptFromTerminal()
+	}
+
+	// One output format at a time
+	if opts.Print0 {
+		opts.List = true
+	}
+	if opts.JSON && opts.Quiet || opts.List && (opts.JSON || opts.Quiet) {
+		fmt.Fprintf(console, "%s --json, --quiet and --list cannot be combined\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+	if opts.List && (opts.Top > 0 || delf.CountDeleteModes(deleteOptions()) > 0) {
+		fmt.Fprintf(console, "%s --list only prints paths; it cannot be combined with --top, --trash, --archive, --move-to or --shred\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+
+	// Depth range
+	if opts.MaxDepth > 0 && opts.MinDepth > opts.MaxDepth {
+		fmt.Fprintf(console, "%s --min-depth cannot be greater than --max-depth\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+
+	// Metadata filters
+	var err error
+	if filter, err = newSearchFilter(time.Now()); err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+
+	// Retention rules
+	if retention, err = newRetention(); err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+
+	// Only one deletion strategy at a time
+	if delf.CountDeleteModes(deleteOptions()) > 1 {
+		fmt.Fprintf(console, "%s --trash, --archive, --move-to and --shred cannot be combined\n", colors.Red("ERROR:"))
+		os.Exit(1)
+	}
+
+	// Archive type and compressor must be usable before anything is searched
+	if opts.Archive != "" {
+		if err := delf.ValidateArchivePath(opts.Archive); err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			os.Exit(1)
+		}
+		// An archive inside a search path could be matched while it is written
+		if err := delf.CheckDestination("archive", opts.Archive, deleteOptions().Roots); err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			os.Exit(1)
+		}
+	}
+
+	// A quarantine inside a search path would be searched again
+	if opts.MoveTo != "" {
+		if err := delf.CheckDestination("move destination", opts.MoveTo, deleteOptions().Roots); err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			os.Exit(1)
+		}
+	}
+
+	// Git-aware safety needs the git binary
+	if opts.GitSafe && !delf.HasGit() {
+		fmt.Fprintf(console, "%s --git-safe requires 'git' in PATH\n", colors.Red("ERROR:"))
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParseArgsPattern(t *testing.T) {
+	tests := []struct {
+		args    []string
+		pattern string
+		roots   []string
+	}{
+		{[]string{"*.tmp"}, "*.tmp", nil},
+		{[]string{"*.tmp", "a", "b"}, "*.tmp", []string{"a", "b"}},
+		{[]string{"--", "log"}, "log", nil},
+		{[]string{"-n", "--", "run", "/srv"}, "run", []string{"/srv"}},
+		{[]string{"-p", "log"}, "log", nil},
+		{[]string{"--pattern", "du", "a", "b"}, "du", []string{"a", "b"}},
+		{[]string{"-p", "*.tmp", "--", "-odd-dir"}, "*.tmp", []string{"-odd-dir"}},
+	}
+	for _, tt := range tests {
+		savedArgs, savedFlags, savedOpts := os.Args, flag.CommandLine, opts
+		os.Args = append([]string{"delf"}, tt.args...)
+		flag.CommandLine = flag.NewFlagSet("delf", flag.ExitOnError)
+		opts = Options{}
+
+		parseArgs()
+		if opts.Pattern != tt.pattern || !reflect.DeepEqual(opts.Roots, tt.roots) {
+			t.Errorf("delf %q: pattern %q, roots %q; want %q, %q", tt.args, opts.Pattern, opts.Roots, tt.pattern, tt.roots)
+		}
+		os.Args, flag.CommandLine, opts = savedArgs, savedFlags, savedOpts
+	}
+}
+
+// TestSecondSignalKills runs itself as a child that catches one interrupt
+// through withSignals and must then be killed by the next one
+func TestSecondSignalKills(t *testing.T) {
+	if os.Getenv("DELF_SIGNAL_CHILD") == "1" {
+		ctx, _ := withSignals()
+		self, _ := os.FindProcess(os.Getpid())
+		self.Signal(os.Interrupt)
+		<-ctx.Done()
+		for i := 0; i < 100; i++ {
+			self.Signal(os.Interrupt)
+			time.Sleep(20 * time.Millisecond)
+		}
+		os.Exit(0)
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupts cannot be sent to a process on Windows")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSecondSignalKills$")
+	cmd.Env = append(os.Environ(), "DELF_SIGNAL_CHILD=1")
+	err := cmd.Run()
+	exit, ok := err.(*exec.ExitError)
+	if !ok || exit.Exited() {
+		t.Fatalf("child survived the second interrupt: %v", err)
+	}
+}
+
+func TestFromFileWithPattern(t *testing.T) {
+	recordRun(t)
+	savedArgs, savedFlags, savedList := os.Args, flag.CommandLine, pathList
+	defer func() { os.Args, flag.CommandLine, pathList = savedArgs, savedFlags, savedList }()
+
+	root := t.TempDir()
+	for _, name := range []string{"a.log", "b.txt", "logs/c.log"} {
+		touchFile(t, filepath.Join(root, name))
+	}
+	list := filepath.Join(t.TempDir(), "list")
+	data := "a.log\x00\x00b.txt\x00gone.log\x00" + filepath.Join(root, "logs", "c.log") + "\x00"
+	if err := os.WriteFile(list, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Args = []string{"delf", "--from-file", list, "-0", "*.log", root}
+	flag.CommandLine = flag.NewFlagSet("delf", flag.ExitOnError)
+	opts = Options{}
+	parseArgs()
+	if f, ok := pathList.(*os.File); ok {
+		defer f.Close()
+	}
+
+	results, err := search(context.Background(), opts.Pattern, opts.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range results {
+		rel, _ := filepath.Rel(root, r.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	if want := []string{"a.log", "logs/c.log"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("listed matches %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,476 @@
+package delf
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// archiveFormat describes the container and compression chosen from the file name
+type archiveFormat struct {
+	zip      bool
+	compress string // "", "gzip", "zstd" or "xz"
+}
+
+// archiveFormatFor picks the format from the archive extension
+func archiveFormatFor(path string) (archiveFormat, error) {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return archiveFormat{zip: true}, nil
+	case strings.HasSuffix(name, ".tar"):
+		return archiveFormat{}, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return archiveFormat{compress: "gzip"}, nil
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return archiveFormat{compress: "zstd"}, nil
+	case strings.HasSuffix(name, ".tar.xz"), strings.HasSuffix(name, ".txz"):
+		return archiveFormat{compress: "xz"}, nil
+	}
+	return archiveFormat{}, fmt.Errorf("unsupported archive type: %s (use .tar, .tar.gz, .tar.zst, .tar.xz or .zip)", filepath.Base(path))
+}
+
+// ValidateArchivePath checks the archive type, that it does not exist yet
+// and that any external compressor it needs is installed
+func ValidateArchivePath(path string) error {
+	format, err := archiveFormatFor(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("archive %s already exists", path)
+	}
+	if format.compress == "zstd" || format.compress == "xz" {
+		if _, err := exec.LookPath(format.compress); err != nil {
+			return fmt.Errorf("'%s' is required to write %s", format.compress, filepath.Base(path))
+		}
+	}
+	return nil
+}
+
+// archiveEntry is what was written for one archive member, used for verification
+type archiveEntry struct {
+	size int64
+	sum  string
+}
+
+// ArchiveDeleter streams every result into an archive, verifies it, and only
+// then deletes exactly the results that were archived in full
+type ArchiveDeleter struct {
+	Path  string   // archive file; the extension picks the format
+	Root  string   // member names are relative to this directory
+	Roots []string // search roots; the archive may not be written inside one
+
+	entries    map[string]archiveEntry
+	unreadable []Result
+}
+
+// Prepare writes and verifies the archive; nothing is deleted if either fails.
+// Results that could not be read are left out of the archive and kept on disk.
+func (d *ArchiveDeleter) Prepare(results []Result) ([]Result, error) {
+	format, err := archiveFormatFor(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.checkPlacement(results); err != nil {
+		return nil, err
+	}
+	if err := ensureParent(d.Path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(d.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := newArchiveWriter(f, format)
+	if err != nil {
+		f.Close()
+		os.Remove(d.Path)
+		return nil, err
+	}
+
+	d.entries = make(map[string]archiveEntry)
+	d.unreadable = nil
+	var archived []Result
+	for _, result := range results {
+		complete, err := d.addResult(w, result.Path)
+		if err != nil {
+			w.Close()
+			f.Close()
+			os.Remove(d.Path)
+			return nil, fmt.Errorf("writing %s: %w", d.Path, err)
+		}
+		if complete {
+			archived = append(archived, result)
+		} else {
+			d.unreadable = append(d.unreadable, result)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		f.Close()
+		os.Remove(d.Path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(d.Path)
+		return nil, err
+	}
+
+	// An archive that does not read back is of no use for restoring
+	if err := verifyArchive(d.Path, format, d.entries); err != nil {
+		os.Remove(d.Path)
+		return nil, fmt.Errorf("archive verification failed, nothing deleted: %w", err)
+	}
+	return archived, nil
+}
+
+// checkPlacement rejects an archive that a search could match, or that lies
+// in a directory about to be deleted
+func (d *ArchiveDeleter) checkPlacement(results []Result) error {
+	path, err := filepath.Abs(d.Path)
+	if err != nil {
+		return err
+	}
+	if err := CheckDestination("archive", d.Path, d.Roots); err != nil {
+		return err
+	}
+	for _, result := range results {
+		dir, err := filepath.Abs(result.Path)
+		if err == nil && IsInside(path, dir) {
+			return fmt.Errorf("archive %s is inside %s, which is being deleted", d.Path, result.Path)
+		}
+	}
+	return nil
+}
+
+// Entries returns how many members were written and verified
+func (d *ArchiveDeleter) Entries() int {
+	return len(d.entries)
+}
+
+// Unreadable returns the results Prepare left out because they could not be read
+func (d *ArchiveDeleter) Unreadable() []Result {
+	return d.unreadable
+}
+
+func (d *ArchiveDeleter) Delete(result Result) error {
+	return RemovePath(result.Path)
+}
+
+func (d *ArchiveDeleter) Verb() string    { return "Archived and deleted" }
+func (d *ArchiveDeleter) Heading() string { return "Archiving to " + d.Path + "..." }
+
+// addResult adds a file, or a directory and everything below it, to the archive.
+// It reports false when something could not be read, so the result must not be deleted.
+func (d *ArchiveDeleter) addResult(w archiveWriter, path string) (bool, error) {
+	complete := true
+	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			complete = false
+			return nil
+		}
+
+		link := ""
+		var content io.ReadCloser
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			if link, err = os.Readlink(p); err != nil {
+				complete = false
+				return nil
+			}
+		case info.Mode().IsRegular():
+			if content, err = os.Open(p); err != nil {
+				complete = false
+				return nil
+			}
+			defer content.Close()
+		}
+
+		name := filepath.ToSlash(RelativeToRoot(d.Root, p))
+		h := sha256.New()
+		var src io.Reader
+		if content != nil {
+			src = io.TeeReader(content, h)
+		}
+		size, err := w.add(name, info, link, src)
+		if err != nil {
+			return err
+		}
+		d.entries[name] = archiveEntry{size: size, sum: fmt.Sprintf("%x", h.Sum(nil))}
+		return nil
+	})
+	return complete, err
+}
+
+// archiveWriter adds members to a tar or zip archive
+type archiveWriter interface {
+	// add writes one member, copying content when it is a regular file, and returns the bytes copied
+	add(name string, info os.FileInfo, link string, content io.Reader) (int64, error)
+	Close() error
+}
+
+// newArchiveWriter wraps f in the container and compression for format
+func newArchiveWriter(f *os.File, format archiveFormat) (archiveWriter, error) {
+	if format.zip {
+		return &zipWriter{zw: zip.NewWriter(f)}, nil
+	}
+
+	var out io.WriteCloser
+	var wait func() error
+	switch format.compress {
+	case "gzip":
+		out = gzip.NewWriter(f)
+	case "zstd", "xz":
+		cmd := exec.Command(format.compress, "-q", "-c")
+		cmd.Stdout = f
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		out = stdin
+		wait = func() error {
+			if err := cmd.Wait(); err != nil {
+				return fmt.Errorf("%s: %s", format.compress, strings.TrimSpace(stderr.String()))
+			}
+			return nil
+		}
+	}
+
+	var dst io.Writer = f
+	if out != nil {
+		dst = out
+	}
+	return &tarWriter{tw: tar.NewWriter(dst), out: out, wait: wait}, nil
+}
+
+// tarWriter writes a (possibly compressed) tar stream
+type tarWriter struct {
+	tw   *tar.Writer
+	out  io.WriteCloser // compressor, nil for a plain tar
+	wait func() error   // waits for an external compressor
+}
+
+func (w *tarWriter) add(name string, info os.FileInfo, link string, content io.Reader) (int64, error) {
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return 0, err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return 0, err
+	}
+	if content == nil {
+		return 0, nil
+	}
+	return io.Copy(w.tw, content)
+}
+
+func (w *tarWriter) Close() error {
+	err := w.tw.Close()
+	if w.out != nil {
+		if cerr := w.out.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if w.wait != nil {
+		if werr := w.wait(); err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// zipWriter writes a deflate-compressed zip archive
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+func (w *zipWriter) add(name string, info os.FileInfo, link string, content io.Reader) (int64, error) {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return 0, err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	} else {
+		header.Method = zip.Deflate
+	}
+
+	out, err := w.zw.CreateHeader(header)
+	if err != nil {
+		return 0, err
+	}
+	if link != "" {
+		// Zip stores a symlink's target as its content
+		_, err = io.WriteString(out, link)
+		return 0, err
+	}
+	if content == nil {
+		return 0, nil
+	}
+	return io.Copy(out, content)
+}
+
+func (w *zipWriter) Close() error {
+	return w.zw.Close()
+}
+
+// verifyArchive reads the archive back and checks every member written
+// against the size and SHA-256 recorded while writing it
+func verifyArchive(path string, format archiveFormat, entries map[string]archiveEntry) error {
+	seen := make(map[string]bool)
+	check := func(name string, content io.Reader) error {
+		name = strings.TrimSuffix(name, "/")
+		want, ok := entries[name]
+		if !ok {
+			return nil
+		}
+		h := sha256.New()
+		size, err := copyHash(h, content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if size != want.size || fmt.Sprintf("%x", h.Sum(nil)) != want.sum {
+			return fmt.Errorf("%s: contents differ from the original", name)
+		}
+		seen[name] = true
+		return nil
+	}
+
+	var err error
+	if format.zip {
+		err = verifyZip(path, check)
+	} else {
+		err = verifyTar(path, format, check)
+	}
+	if err != nil {
+		return err
+	}
+
+	for name := range entries {
+		if !seen[name] {
+			return fmt.Errorf("%s: missing from archive", name)
+		}
+	}
+	return nil
+}
+
+// copyHash hashes content, treating nil as empty
+func copyHash(h hash.Hash, content io.Reader) (int64, error) {
+	if content == nil {
+		return 0, nil
+	}
+	return io.Copy(h, content)
+}
+
+// verifyZip walks every member of a zip archive
+func verifyZip(path string, check func(string, io.Reader) error) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, file := range zr.File {
+		if file.Mode().IsRegular() {
+			rc, err := file.Open()
+			if err != nil {
+				return err
+			}
+			err = check(file.Name, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := check(file.Name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyTar walks every member of a (possibly compressed) tar archive
+func verifyTar(path string, format archiveFormat, check func(string, io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var in io.Reader = f
+	var cmd *exec.Cmd
+	switch format.compress {
+	case "gzip":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		in = gz
+	case "zstd", "xz":
+		cmd = exec.Command(format.compress, "-q", "-d", "-c")
+		cmd.Stdin = f
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+		in = stdout
+	}
+
+	err = readTar(in, check)
+	if cmd != nil {
+		if err != nil {
+			cmd.Process.Kill()
+		}
+		if werr := cmd.Wait(); err == nil && werr != nil {
+			err = fmt.Errorf("%s: %w", format.compress, werr)
+		}
+	}
+	return err
+}
+
+// readTar passes every member of a tar stream to check
+func readTar(in io.Reader, check func(string, io.Reader) error) error {
+	tr := tar.NewReader(in)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var content io.Reader
+		if header.Typeflag == tar.TypeReg {
+			content = tr
+		}
+		if err := check(header.Name, content); err != nil {
+			return err
+		}
+	}
+}
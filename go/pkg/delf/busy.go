@@ -0,0 +1,91 @@
+package delf
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Holder identifies a process that has a file open
+type Holder struct {
+	PID     int
+	Command string
+}
+
+// String formats the holder as "PID 1234 (command)"
+func (h Holder) String() string {
+	return fmt.Sprintf("PID %d (%s)", h.PID, h.Command)
+}
+
+// FormatHolders joins holders into a single line
+func FormatHolders(holders []Holder) string {
+	parts := make([]string, len(holders))
+	for i, h := range holders {
+		parts[i] = h.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// OpenFiles is a snapshot of the files held open by running processes
+type OpenFiles map[string][]Holder
+
+// SnapshotOpenFiles finds the files currently held open. It is empty where
+// open files cannot be inspected (everywhere but Linux).
+func SnapshotOpenFiles() (OpenFiles, error) {
+	open, err := openFileHolders()
+	return OpenFiles(open), err
+}
+
+// Holders returns the processes holding path open.
+// Directories are busy when anything inside them is open.
+func (o OpenFiles) Holders(path string, isDir bool) []Holder {
+	if len(o) == 0 {
+		return nil
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if !isDir {
+		return o[path]
+	}
+
+	var holders []Holder
+	prefix := path + string(filepath.Separator)
+	seen := make(map[int]bool)
+	for openPath, pathHolders := range o {
+		if openPath != path && !strings.HasPrefix(openPath, prefix) {
+			continue
+		}
+		for _, h := range pathHolders {
+			if !seen[h.PID] {
+				seen[h.PID] = true
+				holders = append(holders, h)
+			}
+		}
+	}
+	return holders
+}
+
+// AnnotateOpenFiles records which processes hold each result open
+func AnnotateOpenFiles(results []Result) ([]Result, error) {
+	open, err := SnapshotOpenFiles()
+	if err != nil || len(open) == 0 {
+		return results, err
+	}
+
+	for i, result := range results {
+		results[i].Holders = open.Holders(result.Path, result.IsDir)
+	}
+	return results, nil
+}
+
+// CountBusy counts results held open by a process
+func CountBusy(results []Result) int {
+	busy := 0
+	for _, r := range results {
+		if len(r.Holders) > 0 {
+			busy++
+		}
+	}
+	return busy
+}
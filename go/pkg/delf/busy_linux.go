@@ -0,0 +1,69 @@
+package delf
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// openFileHolders scans /proc for files held open or mapped by any process.
+// Processes we are not allowed to inspect are skipped silently.
+func openFileHolders() (map[string][]Holder, error) {
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	self := os.Getpid()
+	open := make(map[string][]Holder)
+
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil || pid == self {
+			continue
+		}
+
+		procDir := filepath.Join("/proc", proc.Name())
+		comm, _ := os.ReadFile(filepath.Join(procDir, "comm"))
+		holder := Holder{PID: pid, Command: strings.TrimSpace(string(comm))}
+
+		seen := make(map[string]bool)
+		add := func(path string) {
+			if !seen[path] {
+				seen[path] = true
+				open[path] = append(open[path], holder)
+			}
+		}
+
+		// Open file descriptors
+		fds, _ := os.ReadDir(filepath.Join(procDir, "fd"))
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(procDir, "fd", fd.Name()))
+			if err == nil && strings.HasPrefix(target, "/") {
+				add(strings.TrimSuffix(target, " (deleted)"))
+			}
+		}
+
+		// Memory-mapped files (shared libraries, mmap'd databases)
+		maps, err := os.Open(filepath.Join(procDir, "maps"))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(maps)
+		for scanner.Scan() {
+			fields := strings.SplitN(scanner.Text(), " ", 6)
+			if len(fields) < 6 {
+				continue
+			}
+			path := strings.TrimSpace(fields[5])
+			if strings.HasPrefix(path, "/") {
+				add(strings.TrimSuffix(path, " (deleted)"))
+			}
+		}
+		maps.Close()
+	}
+
+	return open, nil
+}
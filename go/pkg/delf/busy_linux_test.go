@@ -0,0 +1,72 @@
+package delf
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// holdOpen starts a process with path open on its stdin until the test ends
+func holdOpen(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cmd := exec.Command("sleep", "60")
+	cmd.Stdin = f
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start a holder process: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return cmd.Process.Pid
+}
+
+func TestAnnotateOpenFiles(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "logs")
+	held := filepath.Join(dir, "held.log")
+	closed := filepath.Join(root, "closed.log")
+	touch(t, held)
+	touch(t, closed)
+
+	// A file this process opened and closed again is not busy
+	if f, err := os.Open(closed); err == nil {
+		f.Close()
+	}
+	pid := holdOpen(t, held)
+
+	results, err := AnnotateOpenFiles([]Result{{Path: held}, {Path: closed}, {Path: dir, IsDir: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		busy := r.Path != closed
+		if (len(r.Holders) > 0) != busy {
+			t.Errorf("%s holders = %v, want busy %v", r.Path, r.Holders, busy)
+			continue
+		}
+		if busy && (r.Holders[0].PID != pid || r.Holders[0].Command != "sleep") {
+			t.Errorf("%s holders = %v, want PID %d (sleep)", r.Path, r.Holders, pid)
+		}
+	}
+	if n := CountBusy(results); n != 2 {
+		t.Errorf("CountBusy = %d, want 2", n)
+	}
+}
+
+func TestOpenFilesHoldersSiblingPrefix(t *testing.T) {
+	open := OpenFiles{"/data/logs2/a.log": {{PID: 1, Command: "tail"}}}
+	if h := open.Holders("/data/logs", true); h != nil {
+		t.Fatalf("/data/logs is busy through its sibling: %v", h)
+	}
+	if h := open.Holders("/data/logs2", true); len(h) != 1 {
+		t.Fatalf("/data/logs2 holders = %v", h)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package delf
+
+// openFileHolders is only implemented on Linux, where /proc exposes open files
+func openFileHolders() (map[string][]Holder, error) {
+	return nil, nil
+}
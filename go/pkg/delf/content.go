@@ -0,0 +1,172 @@
+package delf
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContentFilter matches files by what is inside them. It only runs on
+// candidates that passed every metadata filter, and reads each file at most twice.
+type ContentFilter struct {
+	Mime       []string // MIME type globs, e.g. image/*
+	Kind       string   // text, binary, archive or media
+	Executable bool
+	Contains   *regexp.Regexp // some line must match
+}
+
+// ContentKinds are the values ContentFilter.Kind accepts
+var ContentKinds = []string{"text", "binary", "archive", "media"}
+
+// Active reports whether any content filter is set
+func (f ContentFilter) Active() bool {
+	return len(f.Mime) > 0 || f.Kind != "" || f.Executable || f.Contains != nil
+}
+
+// match checks one entry; directories and special files never match
+func (f ContentFilter) match(path string, info os.FileInfo) (bool, error) {
+	if !f.Active() {
+		return true, nil
+	}
+	if !info.Mode().IsRegular() {
+		return false, nil
+	}
+	if f.Executable && !isExecutableFile(path, info) {
+		return false, nil
+	}
+	if len(f.Mime) == 0 && f.Kind == "" && f.Contains == nil {
+		return true, nil
+	}
+
+	head, err := readHead(path)
+	if err != nil {
+		return false, err
+	}
+	mimeType := DetectMime(path, head)
+	text := isText(head)
+
+	if len(f.Mime) > 0 && !matchesMime(mimeType, f.Mime) {
+		return false, nil
+	}
+	if f.Kind != "" && contentKind(mimeType, text) != f.Kind {
+		return false, nil
+	}
+	if f.Contains != nil {
+		if !text {
+			return false, nil
+		}
+		return fileContains(path, f.Contains)
+	}
+	return true, nil
+}
+
+// readHead reads the first 512 bytes, which is all MIME sniffing looks at
+func readHead(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// archiveMagic covers compressed and archive formats net/http does not sniff
+var archiveMagic = []struct {
+	offset int
+	magic  string
+	mime   string
+}{
+	{0, "\xFD7zXZ\x00", "application/x-xz"},
+	{0, "\x28\xB5\x2F\xFD", "application/zstd"},
+	{0, "BZh", "application/x-bzip2"},
+	{0, "7z\xBC\xAF\x27\x1C", "application/x-7z-compressed"},
+	{257, "ustar", "application/x-tar"},
+}
+
+// DetectMime sniffs the content type from the first bytes of a file,
+// using the extension when the content is inconclusive
+func DetectMime(path string, head []byte) string {
+	for _, m := range archiveMagic {
+		if len(head) >= m.offset+len(m.magic) && string(head[m.offset:m.offset+len(m.magic)]) == m.magic {
+			return m.mime
+		}
+	}
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if sniffed == "application/octet-stream" || sniffed == "text/plain" {
+		if byExt, _, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(path))); err == nil {
+			return byExt
+		}
+	}
+	return sniffed
+}
+
+// matchesMime reports whether mimeType matches any of the globs
+func matchesMime(mimeType string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, mimeType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isText treats content as text when it has no NUL bytes and is valid UTF-8
+func isText(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+	// The head may end in the middle of a multi-byte character
+	for i := 0; i < utf8.UTFMax && len(head) > 0 && !utf8.Valid(head); i++ {
+		head = head[:len(head)-1]
+	}
+	return utf8.Valid(head)
+}
+
+// contentKind groups a file into one of the --kind categories
+func contentKind(mimeType string, text bool) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"), strings.HasPrefix(mimeType, "audio/"), strings.HasPrefix(mimeType, "video/"):
+		return "media"
+	case mimeType == "application/zip", mimeType == "application/x-gzip", mimeType == "application/gzip",
+		mimeType == "application/x-rar-compressed", mimeType == "application/x-tar",
+		strings.HasPrefix(mimeType, "application/x-xz"), mimeType == "application/zstd",
+		mimeType == "application/x-bzip2", mimeType == "application/x-7z-compressed":
+		return "archive"
+	case text:
+		return "text"
+	}
+	return "binary"
+}
+
+// fileContains reports whether any line of the file matches re
+func fileContains(path string, re *regexp.Regexp) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if re.Match(scanner.Bytes()) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
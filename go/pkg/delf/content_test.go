@@ -0,0 +1,167 @@
+package delf
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// contentFixtures are small files covering each kind of content
+var contentFixtures = []struct {
+	name string
+	data string
+	mime string
+	kind string
+}{
+	{"notes.txt", "hello\nworld\n", "text/plain", "text"},
+	{"README", "plain words\n", "text/plain", "text"},
+	{"page.html", "<html><body>hi</body></html>", "text/html", "text"},
+	{"data.json", `{"a": 1}`, "application/json", "text"},
+	{"utf8.txt", "café naïve 日本", "text/plain", "text"},
+	{"empty", "", "text/plain", "text"},
+	{"blob", "\x00\x01\x02\x03", "application/octet-stream", "binary"},
+	{"nul.txt", "text with a \x00 in it", "text/plain", "binary"},
+	{"pic.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png", "media"},
+	{"a.gz", "\x1f\x8b\x08\x00\x00\x00\x00\x00", "application/x-gzip", "archive"},
+	{"a.xz", "\xFD7zXZ\x00\x00\x04", "application/x-xz", "archive"},
+	{"a.zst", "\x28\xB5\x2F\xFD\x00", "application/zstd", "archive"},
+	{"a.tar", strings.Repeat("\x00", 257) + "ustar\x0000" + strings.Repeat("\x00", 243), "application/x-tar", "archive"},
+}
+
+// writeFixtures writes contentFixtures into a temporary directory
+func writeFixtures(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, f := range contentFixtures {
+		if err := os.WriteFile(filepath.Join(dir, f.name), []byte(f.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDetectMime(t *testing.T) {
+	dir := writeFixtures(t)
+	for _, f := range contentFixtures {
+		path := filepath.Join(dir, f.name)
+		head, err := readHead(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mimeType := DetectMime(path, head)
+		if mimeType != f.mime {
+			t.Errorf("DetectMime(%s) = %q, want %q", f.name, mimeType, f.mime)
+		}
+		if kind := contentKind(mimeType, isText(head)); kind != f.kind {
+			t.Errorf("%s is %q, want %q", f.name, kind, f.kind)
+		}
+	}
+}
+
+func TestIsText(t *testing.T) {
+	tests := []struct {
+		head string
+		want bool
+	}{
+		{"", true},
+		{"plain", true},
+		{"café", true},
+		{"caf\xc3", true}, // cut inside a two-byte character
+		{"日\xe6\x9c", true},
+		{"bad \xff\xfe bytes", false},
+		{"nul \x00", false},
+	}
+	for _, tt := range tests {
+		if got := isText([]byte(tt.head)); got != tt.want {
+			t.Errorf("isText(%q) = %v, want %v", tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestContentFilterMatch(t *testing.T) {
+	dir := writeFixtures(t)
+	script := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho TODO\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter ContentFilter
+		want   []string
+	}{
+		{"image prefix", ContentFilter{Mime: []string{"image/*"}}, []string{"pic.png"}},
+		{"exact mime", ContentFilter{Mime: []string{"application/json"}}, []string{"data.json"}},
+		{"several mimes", ContentFilter{Mime: []string{"text/html", "application/x-*"}}, []string{"a.gz", "a.tar", "a.xz", "page.html", "run.sh"}},
+		{"binary", ContentFilter{Kind: "binary"}, []string{"blob", "nul.txt"}},
+		{"archive", ContentFilter{Kind: "archive"}, []string{"a.gz", "a.tar", "a.xz", "a.zst"}},
+		{"media", ContentFilter{Kind: "media"}, []string{"pic.png"}},
+		{"contains", ContentFilter{Contains: regexp.MustCompile(`hello|TODO`)}, []string{"notes.txt", "run.sh"}},
+		{"contains skips binary", ContentFilter{Contains: regexp.MustCompile(`text`)}, nil},
+		{"text and contains", ContentFilter{Kind: "text", Contains: regexp.MustCompile(`^$`)}, nil},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct {
+			name   string
+			filter ContentFilter
+			want   []string
+		}{"executable", ContentFilter{Executable: true}, []string{"run.sh"}})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, e := range entries {
+				info, err := e.Info()
+				if err != nil {
+					t.Fatal(err)
+				}
+				ok, err := tt.filter.match(filepath.Join(dir, e.Name()), info)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok {
+					got = append(got, e.Name())
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("matched %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentFilterEmptyAndDirectories(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.log")
+	touch(t, empty)
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Stat(empty)
+	dirInfo, _ := os.Stat(dir)
+
+	text := ContentFilter{Kind: "text"}
+	if ok, err := text.match(empty, info); !ok || err != nil {
+		t.Errorf("empty file is not text: %v", err)
+	}
+	if ok, _ := (ContentFilter{Kind: "binary"}).match(empty, info); ok {
+		t.Error("empty file is binary")
+	}
+	if ok, _ := (ContentFilter{Contains: regexp.MustCompile(`x`)}).match(empty, info); ok {
+		t.Error("empty file contains x")
+	}
+	if ok, _ := text.match(dir, dirInfo); ok {
+		t.Error("a directory matched a content filter")
+	}
+	if ok, _ := (ContentFilter{}).match(dir, dirInfo); !ok {
+		t.Error("an inactive content filter rejected a directory")
+	}
+}
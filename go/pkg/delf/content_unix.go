@@ -0,0 +1,10 @@
+//go:build !windows
+
+package delf
+
+import "os"
+
+// isExecutableFile checks the execute permission bits
+func isExecutableFile(path string, info os.FileInfo) bool {
+	return info.Mode().Perm()&0111 != 0
+}
@@ -0,0 +1,16 @@
+package delf
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// executableExtensions are the file types Windows runs directly
+var executableExtensions = []string{".exe", ".bat", ".cmd", ".ps1", ".com"}
+
+// isExecutableFile has no mode bits to go on, so it checks the extension
+func isExecutableFile(path string, info os.FileInfo) bool {
+	return slices.Contains(executableExtensions, strings.ToLower(filepath.Ext(path)))
+}
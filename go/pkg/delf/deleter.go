@@ -0,0 +1,167 @@
+package delf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Deleter disposes of search results one at a time
+type Deleter interface {
+	// Delete removes (or relocates) the path of a single result
+	Delete(result Result) error
+	// Verb is the past tense of what happened to each item, e.g. "Deleted"
+	Verb() string
+	// Heading describes the whole operation, e.g. "Deleting..."
+	Heading() string
+}
+
+// Preparer is implemented by deleters that must see every result before
+// the first Delete. It returns the results that are safe to go ahead with.
+type Preparer interface {
+	Prepare(results []Result) ([]Result, error)
+}
+
+// DeleteOptions selects how results are disposed of. At most one of Trash,
+// Archive, MoveTo and Shred may be set; none means permanent deletion.
+type DeleteOptions struct {
+	Root    string   // search root; archive and quarantine paths are relative to it
+	Roots   []string // every search root, which an archive or quarantine may not be inside
+	Trash   bool
+	Archive string // archive file (.tar, .tar.gz, .tar.zst, .tar.xz or .zip)
+	MoveTo  string // quarantine directory
+	Shred   int    // overwrite passes
+	DryRun  bool
+}
+
+// NewDeleter returns the deleter selected by opts
+func NewDeleter(opts DeleteOptions) (Deleter, error) {
+	if CountDeleteModes(opts) > 1 {
+		return nil, fmt.Errorf("trash, archive, move-to and shred cannot be combined")
+	}
+	root, err := filepath.Abs(opts.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleter Deleter
+	switch {
+	case opts.Trash:
+		deleter = TrashDeleter{}
+	case opts.Archive != "":
+		deleter = &ArchiveDeleter{Path: opts.Archive, Root: root, Roots: opts.Roots}
+	case opts.MoveTo != "":
+		deleter = &MoveDeleter{Dest: opts.MoveTo, Root: root, Roots: opts.Roots}
+	case opts.Shred > 0:
+		deleter = ShredDeleter{Passes: opts.Shred}
+	default:
+		deleter = PermanentDeleter{}
+	}
+
+	if opts.DryRun {
+		deleter = DryRunDeleter{Inner: deleter}
+	}
+	return deleter, nil
+}
+
+// CountDeleteModes counts how many mutually exclusive deletion modes are set
+func CountDeleteModes(opts DeleteOptions) int {
+	modes := 0
+	for _, set := range []bool{opts.Trash, opts.Archive != "", opts.MoveTo != "", opts.Shred > 0} {
+		if set {
+			modes++
+		}
+	}
+	return modes
+}
+
+// CheckDestination rejects a destination inside one of roots, where the search
+// could match what is written there. what names the destination in the error.
+func CheckDestination(what, dest string, roots []string) error {
+	path, err := filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+	for _, root := range roots {
+		if root, err := filepath.Abs(root); err == nil && IsInside(path, root) {
+			return fmt.Errorf("%s %s is inside the search path %s; write it elsewhere", what, dest, root)
+		}
+	}
+	return nil
+}
+
+// RemovePath deletes a single file or directory
+func RemovePath(path string) error {
+	return os.RemoveAll(path)
+}
+
+// PermanentDeleter removes paths for good
+type PermanentDeleter struct{}
+
+func (PermanentDeleter) Delete(result Result) error {
+	return RemovePath(result.Path)
+}
+
+func (PermanentDeleter) Verb() string    { return "Deleted" }
+func (PermanentDeleter) Heading() string { return "Deleting..." }
+
+// ShredDeleter overwrites file contents before removing them
+type ShredDeleter struct {
+	Passes int
+}
+
+func (d ShredDeleter) Delete(result Result) error {
+	return ShredPath(result.Path, d.Passes)
+}
+
+func (ShredDeleter) Verb() string    { return "Shredded" }
+func (ShredDeleter) Heading() string { return "Shredding..." }
+
+// TrashDeleter moves paths to the platform trash / recycle bin
+type TrashDeleter struct{}
+
+func (TrashDeleter) Delete(result Result) error {
+	return moveToTrash(result.Path)
+}
+
+func (TrashDeleter) Verb() string    { return "Trashed" }
+func (TrashDeleter) Heading() string { return "Moving to trash..." }
+
+// DryRunDeleter reports what another deleter would do without touching anything
+type DryRunDeleter struct {
+	Inner Deleter
+}
+
+func (DryRunDeleter) Delete(result Result) error {
+	return nil
+}
+
+func (d DryRunDeleter) Verb() string {
+	return "Would be " + strings.ToLower(d.Inner.Verb())
+}
+
+func (DryRunDeleter) Heading() string { return "Dry run (nothing is removed)..." }
+
+// RelativeToRoot returns path relative to the search root, falling back to
+// the path without its volume/leading separator when it lies outside the root
+func RelativeToRoot(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return rel
+	}
+	path = strings.TrimPrefix(path, filepath.VolumeName(path))
+	return strings.TrimLeft(path, `/\`)
+}
+
+// CloseDeleter finishes a deleter that holds resources, such as an open archive
+func CloseDeleter(deleter Deleter) error {
+	if closer, ok := deleter.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ensureParent creates the parent directory of path
+func ensureParent(path string) error {
+	return os.MkdirAll(filepath.Dir(path), 0755)
+}
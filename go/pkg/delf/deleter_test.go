@@ -0,0 +1,259 @@
+package delf
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// exists reports whether path is still on disk
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func TestNewDeleter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts DeleteOptions
+		verb string
+	}{
+		{"permanent", DeleteOptions{}, "Deleted"},
+		{"trash", DeleteOptions{Trash: true}, "Trashed"},
+		{"archive", DeleteOptions{Archive: "out.tar"}, "Archived and deleted"},
+		{"move", DeleteOptions{MoveTo: "quarantine"}, "Moved"},
+		{"shred", DeleteOptions{Shred: 3}, "Shredded"},
+		{"dry run", DeleteOptions{Trash: true, DryRun: true}, "Would be trashed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := NewDeleter(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Verb() != tt.verb {
+				t.Fatalf("Verb() = %q, want %q", d.Verb(), tt.verb)
+			}
+		})
+	}
+
+	if _, err := NewDeleter(DeleteOptions{Trash: true, MoveTo: "quarantine"}); err == nil {
+		t.Fatal("NewDeleter accepted two deletion modes")
+	}
+}
+
+func TestPermanentDeleter(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "a.tmp")
+	dir := filepath.Join(root, "cache")
+	touch(t, file)
+	touch(t, filepath.Join(dir, "sub", "b.tmp"))
+
+	for _, r := range []Result{{Path: file}, {Path: dir, IsDir: true}} {
+		if err := (PermanentDeleter{}).Delete(r); err != nil {
+			t.Fatalf("Delete(%s): %v", r.Path, err)
+		}
+		if exists(r.Path) {
+			t.Fatalf("%s still exists", r.Path)
+		}
+	}
+}
+
+func TestDryRunDeleter(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "a.tmp")
+	touch(t, file)
+
+	inner := &MoveDeleter{Dest: filepath.Join(root, "quarantine"), Root: root}
+	d := DryRunDeleter{Inner: inner}
+	if err := d.Delete(Result{Path: file}); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(file) {
+		t.Fatal("dry run removed the file")
+	}
+	if exists(inner.Dest) {
+		t.Fatal("dry run created the move destination")
+	}
+	if d.Verb() != "Would be moved" {
+		t.Fatalf("Verb() = %q", d.Verb())
+	}
+}
+
+func TestTrashDeleter(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("trash location is managed by the OS")
+	}
+	root := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	file := filepath.Join(root, "a.tmp")
+	touch(t, file)
+
+	if err := (TrashDeleter{}).Delete(Result{Path: file}); err != nil {
+		t.Fatal(err)
+	}
+	if exists(file) {
+		t.Fatal("trashed file is still in place")
+	}
+	trash := filepath.Join(root, "data", "Trash")
+	if !exists(filepath.Join(trash, "files", "a.tmp")) {
+		t.Fatal("file is not in the trash")
+	}
+	if !exists(filepath.Join(trash, "info", "a.tmp.trashinfo")) {
+		t.Fatal("trash has no .trashinfo record")
+	}
+}
+
+func TestArchiveDeleter(t *testing.T) {
+	for _, ext := range []string{".tar", ".tar.gz", ".zip"} {
+		t.Run(ext, func(t *testing.T) {
+			root := t.TempDir()
+			file := filepath.Join(root, "a.log")
+			dir := filepath.Join(root, "logs")
+			touch(t, file)
+			touch(t, filepath.Join(dir, "b.log"))
+			touch(t, filepath.Join(dir, "old", "c.log"))
+			results := []Result{{Path: file}, {Path: dir, IsDir: true}}
+
+			d := &ArchiveDeleter{Path: filepath.Join(t.TempDir(), "out"+ext), Root: root}
+			archived, err := d.Prepare(results)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(archived) != len(results) {
+				t.Fatalf("archived %d of %d results", len(archived), len(results))
+			}
+			if d.Entries() < 3 {
+				t.Fatalf("archive holds %d entries, want at least 3", d.Entries())
+			}
+			if !exists(file) || !exists(dir) {
+				t.Fatal("Prepare deleted something")
+			}
+			for _, r := range archived {
+				if err := d.Delete(r); err != nil {
+					t.Fatal(err)
+				}
+				if exists(r.Path) {
+					t.Fatalf("%s still exists", r.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestArchiveDeleterKeepsExistingArchive(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "a.log")
+	touch(t, file)
+	archive := filepath.Join(t.TempDir(), "out.tar")
+	touch(t, archive)
+
+	d := &ArchiveDeleter{Path: archive, Root: root}
+	if _, err := d.Prepare([]Result{{Path: file}}); err == nil {
+		t.Fatal("Prepare overwrote an existing archive")
+	}
+	if data, _ := os.ReadFile(archive); string(data) != archive {
+		t.Fatal("existing archive was modified")
+	}
+}
+
+func TestMoveDeleterMirrorsRoot(t *testing.T) {
+	root := t.TempDir()
+	dest := t.TempDir()
+	file := filepath.Join(root, "app", "logs", "a.log")
+	touch(t, file)
+
+	d := &MoveDeleter{Dest: dest, Root: root}
+	if err := d.Delete(Result{Path: file}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if exists(file) {
+		t.Fatal("moved file is still in place")
+	}
+	want := filepath.Join(dest, "app", "logs", "a.log")
+	if !exists(want) {
+		t.Fatalf("%s was not created", want)
+	}
+	entries, err := ReadManifest(d.ManifestPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].From != file || entries[0].To != want {
+		t.Fatalf("manifest = %+v", entries)
+	}
+}
+
+func TestArchiveDeleterPlacement(t *testing.T) {
+	root := t.TempDir()
+	logs := filepath.Join(root, "logs")
+	touch(t, filepath.Join(logs, "a.log"))
+	results := []Result{{Path: logs, IsDir: true}}
+
+	tests := []struct {
+		name  string
+		path  string
+		roots []string
+	}{
+		{"inside the search root", filepath.Join(root, "backup.tar"), []string{root}},
+		{"inside one of several roots", filepath.Join(root, "backup.tar"), []string{t.TempDir(), root}},
+		{"inside a deleted directory", filepath.Join(logs, "backup.tar"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &ArchiveDeleter{Path: tt.path, Root: root, Roots: tt.roots}
+			if _, err := d.Prepare(results); err == nil {
+				t.Fatal("Prepare accepted the archive location")
+			}
+			if exists(tt.path) {
+				t.Fatal("archive was written")
+			}
+		})
+	}
+
+	// Next to the search root is fine
+	d := &ArchiveDeleter{Path: filepath.Join(t.TempDir(), "backup.tar"), Root: root, Roots: []string{root}}
+	if _, err := d.Prepare(results); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckDestination(t *testing.T) {
+	root := t.TempDir()
+	other := t.TempDir()
+	tests := []struct {
+		name  string
+		dest  string
+		roots []string
+		ok    bool
+	}{
+		{"no roots", filepath.Join(root, "out"), nil, true},
+		{"outside", filepath.Join(other, "out"), []string{root}, true},
+		{"the root itself", root, []string{root}, false},
+		{"below the root", filepath.Join(root, "a", "out"), []string{root}, false},
+		{"below the second root", filepath.Join(root, "out"), []string{other, root}, false},
+		{"sibling sharing a prefix", root + "-out", []string{root}, true},
+	}
+	for _, tt := range tests {
+		err := CheckDestination("archive", tt.dest, tt.roots)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: CheckDestination(%s) = %v", tt.name, tt.dest, err)
+		}
+	}
+
+	// Relative destinations and roots are resolved against the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(root); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := CheckDestination("archive", "out.tar", []string{"."}); err == nil {
+		t.Error("relative destination inside a relative root was accepted")
+	}
+}
@@ -0,0 +1,17 @@
+// Package delf finds, classifies and disposes of files the way the delf
+// command does, for use from other Go programs.
+//
+// A Searcher walks a directory tree (using fd when it is installed) and
+// streams matching entries as Results on a channel. A Classifier rates paths
+// as safe, warning-level or critical, and a Deleter removes, trashes,
+// archives, quarantines or shreds results. Nothing in this package prints;
+// callers decide what to show.
+//
+//	searcher := delf.NewSearcher(delf.SearchOptions{Pattern: "*.log", Root: "/var/app"})
+//	for result := range searcher.Search(context.Background()) {
+//		fmt.Println(result.Path, result.Category)
+//	}
+//	if err := searcher.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+package delf
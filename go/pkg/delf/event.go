@@ -0,0 +1,157 @@
+package delf
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// EventKind identifies what an Event reports
+type EventKind int
+
+const (
+	EventResultFound EventKind = iota // a search matched Result
+	EventSkipped                      // Path was passed over; Reason says why
+	EventError                        // Path could not be read or checked
+	EventProgress                     // Count entries have been examined so far
+	EventDeleted                      // Result was disposed of; Verb says how
+	EventFailed                       // disposing of Result failed with Err
+)
+
+// String returns the name used for the kind in JSON output
+func (k EventKind) String() string {
+	switch k {
+	case EventResultFound:
+		return "result"
+	case EventSkipped:
+		return "skipped"
+	case EventError:
+		return "error"
+	case EventProgress:
+		return "progress"
+	case EventDeleted:
+		return "deleted"
+	case EventFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is something that happened during a search or deletion. Only the
+// fields that apply to its Kind are set.
+type Event struct {
+	Kind   EventKind
+	Path   string
+	Result Result // ResultFound, Deleted, Failed and busy Skipped events
+	Reason string // Skipped
+	Verb   string // Deleted
+	Err    error  // Error and Failed
+	Count  int    // Progress
+}
+
+// Sink receives events. Searches call it from their own goroutines, one event at a time.
+type Sink interface {
+	Event(e Event)
+}
+
+// SinkFunc adapts a function to the Sink interface
+type SinkFunc func(e Event)
+
+// Event calls f(e)
+func (f SinkFunc) Event(e Event) {
+	f(e)
+}
+
+// Discard is a Sink that ignores every event
+var Discard Sink = SinkFunc(func(Event) {})
+
+// Recorder is a Sink that keeps every event, for tests and for callers that
+// want to inspect a run afterwards
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Event records e
+func (r *Recorder) Event(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// Events returns the recorded events of the given kinds, or all of them when none are given
+func (r *Recorder) Events(kinds ...EventKind) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []Event
+	for _, e := range r.events {
+		if len(kinds) == 0 || containsKind(kinds, e.Kind) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func containsKind(kinds []EventKind, kind EventKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONSink writes each event as one JSON line
+type JSONSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONSink returns a Sink writing JSON lines to w
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{enc: json.NewEncoder(w)}
+}
+
+// jsonEvent is the JSON form of an Event
+type jsonEvent struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Path     string    `json:"path,omitempty"`
+	Root     string    `json:"root,omitempty"`
+	IsDir    bool      `json:"is_dir,omitempty"`
+	Category string    `json:"category,omitempty"`
+	Nested   int       `json:"nested,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	Verb     string    `json:"verb,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Count    int       `json:"count,omitempty"`
+}
+
+// Event writes e; write errors are ignored
+func (s *JSONSink) Event(e Event) {
+	record := jsonEvent{
+		Time:   time.Now(),
+		Event:  e.Kind.String(),
+		Path:   e.Path,
+		Reason: e.Reason,
+		Verb:   e.Verb,
+		Count:  e.Count,
+	}
+	if e.Result.Path != "" {
+		record.Path = e.Result.Path
+		record.Root = e.Result.Root
+		record.IsDir = e.Result.IsDir
+		record.Category = e.Result.Category.String()
+		record.Nested = e.Result.Nested
+	}
+	if e.Err != nil {
+		record.Error = e.Err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(record)
+}
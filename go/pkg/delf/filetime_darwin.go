@@ -0,0 +1,24 @@
+package delf
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileTime returns the selected timestamp from the stat data
+func fileTime(path string, info os.FileInfo, field string) (time.Time, error) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	switch {
+	case field == "mtime":
+		return info.ModTime(), nil
+	case field == "atime" && ok:
+		return time.Unix(st.Atimespec.Unix()), nil
+	case field == "ctime" && ok:
+		return time.Unix(st.Ctimespec.Unix()), nil
+	case field == "btime" && ok:
+		return time.Unix(st.Birthtimespec.Unix()), nil
+	}
+	return time.Time{}, fmt.Errorf("%s is not available for %s", field, path)
+}
@@ -0,0 +1,33 @@
+package delf
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileTime returns the selected timestamp; birth time comes from statx
+func fileTime(path string, info os.FileInfo, field string) (time.Time, error) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	switch {
+	case field == "mtime":
+		return info.ModTime(), nil
+	case field == "atime" && ok:
+		return time.Unix(st.Atim.Unix()), nil
+	case field == "ctime" && ok:
+		return time.Unix(st.Ctim.Unix()), nil
+	case field == "btime":
+		var stx unix.Statx_t
+		if err := unix.Statx(unix.AT_FDCWD, path, unix.AT_SYMLINK_NOFOLLOW, unix.STATX_BTIME, &stx); err != nil {
+			return time.Time{}, fmt.Errorf("birth time: %w", err)
+		}
+		if stx.Mask&unix.STATX_BTIME == 0 {
+			return time.Time{}, fmt.Errorf("birth time is not recorded by this filesystem")
+		}
+		return time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec)), nil
+	}
+	return time.Time{}, fmt.Errorf("%s is not available for %s", field, path)
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !windows
+
+package delf
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// fileTime only knows the modification time on this platform
+func fileTime(path string, info os.FileInfo, field string) (time.Time, error) {
+	if field == "mtime" {
+		return info.ModTime(), nil
+	}
+	return time.Time{}, fmt.Errorf("%s is not supported on this platform", field)
+}
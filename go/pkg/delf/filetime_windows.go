@@ -0,0 +1,24 @@
+package delf
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileTime returns the selected timestamp; Windows has no inode change time
+func fileTime(path string, info os.FileInfo, field string) (time.Time, error) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	switch {
+	case field == "mtime":
+		return info.ModTime(), nil
+	case field == "atime" && ok:
+		return time.Unix(0, data.LastAccessTime.Nanoseconds()), nil
+	case field == "btime" && ok:
+		return time.Unix(0, data.CreationTime.Nanoseconds()), nil
+	case field == "ctime":
+		return time.Time{}, fmt.Errorf("ctime is not available on Windows")
+	}
+	return time.Time{}, fmt.Errorf("%s is not available for %s", field, path)
+}
@@ -0,0 +1,223 @@
+package delf
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter narrows search matches by metadata and content. The zero value
+// matches everything; each check is skipped while its fields are unset.
+type Filter struct {
+	OlderThan time.Time // match entries whose timestamp is not after this
+	NewerThan time.Time // match entries whose timestamp is after this
+	TimeField string    // mtime (default), atime, ctime or btime
+
+	Size    *SizeRange
+	Owner   *Ownership
+	Perm    *Permission
+	Content ContentFilter
+}
+
+// SizeRange matches sizes between Min and Max, inclusive
+type SizeRange struct {
+	Min       int64
+	Max       int64 // -1 for no upper bound
+	DirTotals bool  // compare directories by their total size instead of skipping them
+}
+
+// TimeFields are the timestamps Filter.TimeField can select
+var TimeFields = []string{"mtime", "atime", "ctime", "btime"}
+
+// Active reports whether any filter is set, so callers can skip the stat
+func (f Filter) Active() bool {
+	return f.hasTimeFilter() || f.Size != nil || f.Owner != nil || f.Perm != nil || f.Content.Active()
+}
+
+// hasTimeFilter reports whether any age filter is set
+func (f Filter) hasTimeFilter() bool {
+	return !f.OlderThan.IsZero() || !f.NewerThan.IsZero()
+}
+
+// Match applies the filters to one entry, cheapest first so file contents are
+// only read for remaining candidates. An error means the entry could not be
+// evaluated and does not match.
+func (f Filter) Match(path string, info os.FileInfo) (bool, error) {
+	if f.Owner != nil && !f.Owner.match(info) {
+		return false, nil
+	}
+	if f.Perm != nil && !f.Perm.match(info) {
+		return false, nil
+	}
+	if f.Size != nil && !f.Size.match(path, info) {
+		return false, nil
+	}
+	if ok, err := f.matchTime(path, info); !ok {
+		return false, err
+	}
+	return f.Content.match(path, info)
+}
+
+// match checks the size range; directories only count with DirTotals
+func (r SizeRange) match(path string, info os.FileInfo) bool {
+	size := info.Size()
+	if info.IsDir() {
+		if !r.DirTotals {
+			return true
+		}
+		size = PathSize(path)
+	}
+	return size >= r.Min && (r.Max < 0 || size <= r.Max)
+}
+
+// matchTime checks the selected timestamp against the age cutoffs
+func (f Filter) matchTime(path string, info os.FileInfo) (bool, error) {
+	if !f.hasTimeFilter() {
+		return true, nil
+	}
+
+	field := f.TimeField
+	if field == "" {
+		field = "mtime"
+	}
+	t, err := fileTime(path, info, field)
+	if err != nil {
+		return false, err
+	}
+	if !f.OlderThan.IsZero() && t.After(f.OlderThan) {
+		return false, nil
+	}
+	if !f.NewerThan.IsZero() && !t.After(f.NewerThan) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// ParseAge turns an age such as "30" (days), "2h", "3w", "6mo" or an
+// absolute date/timestamp into the point in time it refers to
+func ParseAge(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := ParseDate(value); err == nil {
+		return t, nil
+	}
+
+	i := 0
+	for i < len(value) && value[i] >= '0' && value[i] <= '9' {
+		i++
+	}
+	n, err := strconv.Atoi(value[:i])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid age or date: %s (e.g. 30, 2h, 3w, 6mo, 2024-01-31)", value)
+	}
+
+	switch strings.ToLower(value[i:]) {
+	case "s":
+		return now.Add(-time.Duration(n) * time.Second), nil
+	case "m", "min":
+		return now.Add(-time.Duration(n) * time.Minute), nil
+	case "h":
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case "", "d":
+		return now.AddDate(0, 0, -n), nil
+	case "w":
+		return now.AddDate(0, 0, -7*n), nil
+	case "mo":
+		return now.AddDate(0, -n, 0), nil
+	case "y":
+		return now.AddDate(-n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid age unit in %s (use s, min, h, d, w, mo or y)", value)
+}
+
+// ParseDate accepts RFC 3339 timestamps and local dates with optional time
+func ParseDate(value string) (time.Time, error) {
+	layouts := []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s (use YYYY-MM-DD or RFC 3339)", value)
+}
+
+// sizeUnits maps size suffixes to byte multipliers. Single letters and the
+// IEC forms (KiB) are binary; the SI forms (KB) are decimal.
+var sizeUnits = map[string]float64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10, "KIB": 1 << 10, "KB": 1e3,
+	"M": 1 << 20, "MIB": 1 << 20, "MB": 1e6,
+	"G": 1 << 30, "GIB": 1 << 30, "GB": 1e9,
+	"T": 1 << 40, "TIB": 1 << 40, "TB": 1e12,
+	"P": 1 << 50, "PIB": 1 << 50, "PB": 1e15,
+}
+
+// ParseSize converts size string like "100M", "1.5G" or "500MB" to bytes
+func ParseSize(sizeStr string) (int64, error) {
+	sizeStr = strings.TrimSpace(strings.ToUpper(sizeStr))
+	if sizeStr == "" {
+		return 0, fmt.Errorf("empty size string")
+	}
+
+	i := 0
+	for i < len(sizeStr) && (sizeStr[i] >= '0' && sizeStr[i] <= '9' || sizeStr[i] == '.') {
+		i++
+	}
+	numStr, unit := sizeStr[:i], strings.TrimSpace(sizeStr[i:])
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit: %s", unit)
+	}
+
+	num, err := strconv.ParseFloat(numStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size number: %s", numStr)
+	}
+
+	// Sizes past the int64 range would wrap around to negative byte counts
+	total := num * multiplier
+	if total >= math.MaxInt64 {
+		return 0, fmt.Errorf("size too large: %s", sizeStr)
+	}
+	return int64(total), nil
+}
+
+// ParseSizeRange parses "MIN..MAX", where either end may be left out
+func ParseSizeRange(value string) (SizeRange, error) {
+	from, to, ok := strings.Cut(value, "..")
+	if !ok {
+		return SizeRange{}, fmt.Errorf("invalid range: %s (use MIN..MAX, MIN.. or ..MAX)", value)
+	}
+	r := SizeRange{Max: -1}
+	var err error
+	if strings.TrimSpace(from) != "" {
+		if r.Min, err = ParseSize(from); err != nil {
+			return r, err
+		}
+	}
+	if strings.TrimSpace(to) != "" {
+		if r.Max, err = ParseSize(to); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// Narrow intersects the range with [min, max]; max -1 is unbounded
+func (r *SizeRange) Narrow(min, max int64) {
+	if min > r.Min {
+		r.Min = min
+	}
+	if max >= 0 && (r.Max < 0 || max < r.Max) {
+		r.Max = max
+	}
+}
+
+// Empty reports whether no size can fall inside the range
+func (r SizeRange) Empty() bool {
+	return r.Max >= 0 && r.Min > r.Max
+}
@@ -0,0 +1,147 @@
+package delf
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"1K", 1 << 10},
+		{"1k", 1 << 10},
+		{"1KiB", 1 << 10},
+		{"1KB", 1000},
+		{"1.5M", 3 << 19},
+		{"500MB", 500e6},
+		{"2G", 2 << 30},
+		{"1 TiB", 1 << 40},
+		{"1PB", 1e15},
+		{" 10M ", 10 << 20},
+		{"8191P", 8191 << 50}, // the largest whole P below 2^63
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil {
+			t.Errorf("ParseSize(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseSizeErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"  ",
+		"M",
+		"1X",
+		"1EiB",
+		"1.2.3M",
+		"-5M",
+		"8192P",               // 2^63 bytes
+		"9999999PiB",          // far past int64
+		"9223372036854775808", // math.MaxInt64 + 1
+	} {
+		if got, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+func TestParseSizeRange(t *testing.T) {
+	tests := []struct {
+		in       string
+		min, max int64
+	}{
+		{"1M..10M", 1 << 20, 10 << 20},
+		{"1M..", 1 << 20, -1},
+		{"..10M", 0, 10 << 20},
+		{"..", 0, -1},
+		{" 1K .. 2K ", 1 << 10, 2 << 10},
+	}
+	for _, tt := range tests {
+		r, err := ParseSizeRange(tt.in)
+		if err != nil {
+			t.Errorf("ParseSizeRange(%q): %v", tt.in, err)
+			continue
+		}
+		if r.Min != tt.min || r.Max != tt.max {
+			t.Errorf("ParseSizeRange(%q) = %d..%d, want %d..%d", tt.in, r.Min, r.Max, tt.min, tt.max)
+		}
+	}
+
+	for _, in := range []string{"10M", "1M-10M", "x..10M", "1M..y", "..9999999P"} {
+		if _, err := ParseSizeRange(in); err == nil {
+			t.Errorf("ParseSizeRange(%q) succeeded", in)
+		}
+	}
+}
+
+func TestSizeRangeNarrow(t *testing.T) {
+	r := SizeRange{Max: -1}
+	r.Narrow(100, -1)
+	r.Narrow(0, 1000)
+	r.Narrow(50, 2000)
+	if r.Min != 100 || r.Max != 1000 || r.Empty() {
+		t.Fatalf("narrowed range = %d..%d", r.Min, r.Max)
+	}
+	r.Narrow(1001, -1)
+	if !r.Empty() {
+		t.Fatalf("range %d..%d is not empty", r.Min, r.Max)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"30", now.AddDate(0, 0, -30)},
+		{"30d", now.AddDate(0, 0, -30)},
+		{"45s", now.Add(-45 * time.Second)},
+		{"5m", now.Add(-5 * time.Minute)},
+		{"5min", now.Add(-5 * time.Minute)},
+		{"2h", now.Add(-2 * time.Hour)},
+		{"3w", now.AddDate(0, 0, -21)},
+		{"1mo", now.AddDate(0, -1, 0)},
+		{"6MO", now.AddDate(0, -6, 0)},
+		{"2y", now.AddDate(-2, 0, 0)},
+		{" 7d ", now.AddDate(0, 0, -7)},
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local)},
+		{"2024-01-31 08:30", time.Date(2024, 1, 31, 8, 30, 0, 0, time.Local)},
+		{"2024-01-31 08:30:15", time.Date(2024, 1, 31, 8, 30, 15, 0, time.Local)},
+		{"2024-01-31T08:30:15", time.Date(2024, 1, 31, 8, 30, 15, 0, time.Local)},
+		{"2024-01-31T08:30:15Z", time.Date(2024, 1, 31, 8, 30, 15, 0, time.UTC)},
+		{"2024-01-31T08:30:15+02:00", time.Date(2024, 1, 31, 6, 30, 15, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseAge(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseAge(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseAge(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	// "m" is minutes, never months
+	if m, _ := ParseAge("1m", now); now.Sub(m) != time.Minute {
+		t.Errorf("ParseAge(1m) is %v before now, want 1m", now.Sub(m))
+	}
+
+	for _, in := range []string{"", "d", "3x", "1.5h", "-3d", "3 days", "2024-13-01", "2024-01-31 25:00"} {
+		if got, err := ParseAge(in, now); err == nil {
+			t.Errorf("ParseAge(%q) = %v, want an error", in, got)
+		}
+	}
+}
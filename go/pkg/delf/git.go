@@ -0,0 +1,248 @@
+package delf
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitStatus represents the state of a path inside a git work tree.
+// Values are ordered by how much git cares about the path, so a directory
+// takes the highest status of anything inside it.
+type GitStatus int
+
+const (
+	GitNone GitStatus = iota // not inside a git work tree
+	GitIgnored
+	GitUntracked
+	GitTrackedClean
+	GitTrackedModified
+	GitRepository // the work tree itself or its .git directory
+)
+
+// String returns a short label for the git status
+func (s GitStatus) String() string {
+	switch s {
+	case GitIgnored:
+		return "ignored"
+	case GitTrackedClean:
+		return "tracked"
+	case GitUntracked:
+		return "untracked"
+	case GitTrackedModified:
+		return "modified"
+	case GitRepository:
+		return "repository"
+	default:
+		return ""
+	}
+}
+
+// IsProtected reports whether --git-safe keeps the path. Tracked files, clean
+// or with uncommitted changes, belong to the project; only ignored and
+// untracked files may be deleted.
+func (s GitStatus) IsProtected() bool {
+	return s >= GitTrackedClean
+}
+
+// gitRepo holds the status of every interesting path in one work tree
+type gitRepo struct {
+	root    string
+	status  map[string]GitStatus // slash-separated, relative to root; directories end with "/"
+	tracked map[string]bool
+	dirs    map[string]GitStatus // highest status of anything inside each directory
+}
+
+// HasGit checks if git is available in PATH
+func HasGit() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// findGitRoot walks up from dir looking for a .git entry
+func findGitRoot(dir string, cache map[string]string) string {
+	if root, ok := cache[dir]; ok {
+		return root
+	}
+
+	root := ""
+	if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+		root = dir
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = findGitRoot(parent, cache)
+	}
+
+	cache[dir] = root
+	return root
+}
+
+// runGit runs git in dir and returns its NUL-separated output fields
+func runGit(dir string, args ...string) ([]string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return strings.Split(strings.TrimRight(string(out), "\x00"), "\x00"), nil
+}
+
+// loadGitRepo reads the status and tracked files of the work tree at root
+func loadGitRepo(root string) (*gitRepo, error) {
+	repo := &gitRepo{
+		root:    root,
+		status:  make(map[string]GitStatus),
+		tracked: make(map[string]bool),
+		dirs:    make(map[string]GitStatus),
+	}
+
+	entries, err := runGit(root, "status", "--porcelain=v1", "-z", "--ignored", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		code, path := entry[:2], entry[3:]
+		switch {
+		case code == "??":
+			repo.status[path] = GitUntracked
+		case code == "!!":
+			repo.status[path] = GitIgnored
+		default:
+			repo.status[path] = GitTrackedModified
+			// Renames and copies are followed by the original path
+			if code[0] == 'R' || code[0] == 'C' {
+				i++
+			}
+		}
+	}
+
+	files, err := runGit(root, "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file != "" {
+			repo.tracked[file] = true
+		}
+	}
+
+	// Index every directory once so classifying one is a single lookup
+	for path, status := range repo.status {
+		repo.indexParents(path, status)
+	}
+	for file := range repo.tracked {
+		repo.indexParents(file, GitTrackedClean)
+	}
+
+	return repo, nil
+}
+
+// indexParents raises the recorded status of every directory above path
+func (repo *gitRepo) indexParents(path string, status GitStatus) {
+	for dir := strings.TrimSuffix(path, "/"); ; {
+		i := strings.LastIndexByte(dir, '/')
+		if i < 0 {
+			return
+		}
+		dir = dir[:i]
+		if repo.dirs[dir] < status {
+			repo.dirs[dir] = status
+		}
+	}
+}
+
+// classify returns the git status of a path relative to the work tree root
+func (repo *gitRepo) classify(rel string, isDir bool) GitStatus {
+	// Deleting the work tree or its history loses everything
+	if rel == "." || rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return GitRepository
+	}
+
+	// Paths inside an ignored or untracked directory inherit its status
+	for parent := rel; parent != "."; {
+		parent = filepath.ToSlash(filepath.Dir(parent))
+		if parent == "." {
+			break
+		}
+		if status, ok := repo.status[parent+"/"]; ok {
+			return status
+		}
+	}
+
+	if !isDir {
+		if status, ok := repo.status[rel]; ok {
+			return status
+		}
+		if repo.tracked[rel] {
+			return GitTrackedClean
+		}
+		return GitUntracked
+	}
+
+	if status, ok := repo.status[rel+"/"]; ok {
+		return status
+	}
+
+	// A directory is as risky as the riskiest path inside it. Git records
+	// nothing for a directory without files, so nothing can be lost there.
+	if status, ok := repo.dirs[rel]; ok && status > GitIgnored {
+		return status
+	}
+	return GitIgnored
+}
+
+// AnnotateGitStatus sets the git status of every result inside a work tree
+func AnnotateGitStatus(results []Result) ([]Result, error) {
+	roots := make(map[string]string)
+	repos := make(map[string]*gitRepo)
+
+	for i, result := range results {
+		dir := result.Path
+		if !result.IsDir {
+			dir = filepath.Dir(result.Path)
+		}
+
+		root := findGitRoot(dir, roots)
+		if root == "" {
+			continue
+		}
+
+		repo, ok := repos[root]
+		if !ok {
+			var err error
+			repo, err = loadGitRepo(root)
+			if err != nil {
+				return results, err
+			}
+			repos[root] = repo
+		}
+
+		rel, err := filepath.Rel(root, result.Path)
+		if err != nil {
+			continue
+		}
+		results[i].Git = repo.classify(filepath.ToSlash(rel), result.IsDir)
+	}
+
+	return results, nil
+}
+
+// FilterGitProtected removes results that git tracks
+func FilterGitProtected(results []Result) (kept, protected []Result) {
+	for _, r := range results {
+		if r.Git.IsProtected() {
+			protected = append(protected, r)
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package delf
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// gitRun runs git in dir, failing the test on error
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=delf", "-c", "user.email=delf@example.com"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestGitStatusProtection(t *testing.T) {
+	if !HasGit() {
+		t.Skip("git not in PATH")
+	}
+	root := t.TempDir()
+	gitRun(t, root, "init", "-q")
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("build/\n*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	touch(t, filepath.Join(root, "src", "clean.go"))
+	touch(t, filepath.Join(root, "src", "changed.go"))
+	touch(t, filepath.Join(root, "docs", "guide.md"))
+	gitRun(t, root, "add", ".")
+	gitRun(t, root, "commit", "-q", "-m", "init")
+
+	if err := os.WriteFile(filepath.Join(root, "src", "changed.go"), []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	touch(t, filepath.Join(root, "src", "new.go"))
+	touch(t, filepath.Join(root, "scratch", "notes.txt"))
+	touch(t, filepath.Join(root, "build", "out", "app"))
+	touch(t, filepath.Join(root, "docs", "debug.log"))
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path      string
+		isDir     bool
+		want      GitStatus
+		protected bool
+	}{
+		{"src/clean.go", false, GitTrackedClean, true},
+		{"src/changed.go", false, GitTrackedModified, true},
+		{"src/new.go", false, GitUntracked, false},
+		{"scratch/notes.txt", false, GitUntracked, false},
+		{"build/out/app", false, GitIgnored, false},
+		{"docs/debug.log", false, GitIgnored, false},
+		{".git/config", false, GitRepository, true},
+
+		{"src", true, GitTrackedModified, true},
+		{"docs", true, GitTrackedClean, true},
+		{"scratch", true, GitUntracked, false},
+		{"build", true, GitIgnored, false},
+		{"build/out", true, GitIgnored, false},
+		{"empty", true, GitIgnored, false},
+		{".", true, GitRepository, true},
+	}
+
+	var results []Result
+	for _, tt := range tests {
+		results = append(results, Result{Path: filepath.Join(root, filepath.FromSlash(tt.path)), IsDir: tt.isDir})
+	}
+	results, err := AnnotateGitStatus(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		got := results[i].Git
+		if got != tt.want {
+			t.Errorf("%s: status %s, want %s", tt.path, got, tt.want)
+		}
+		if got.IsProtected() != tt.protected {
+			t.Errorf("%s: IsProtected() = %v, want %v", tt.path, got.IsProtected(), tt.protected)
+		}
+	}
+
+	kept, protected := FilterGitProtected(results)
+	if len(kept)+len(protected) != len(results) {
+		t.Fatalf("FilterGitProtected lost results: %d kept, %d protected of %d", len(kept), len(protected), len(results))
+	}
+	for _, r := range kept {
+		if r.Git != GitIgnored && r.Git != GitUntracked {
+			t.Errorf("%s (%s) kept for deletion", r.Path, r.Git)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package delf
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestEntry records one relocation; the manifest holds one JSON entry per line
+type ManifestEntry struct {
+	Time  time.Time `json:"time"`
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	IsDir bool      `json:"is_dir,omitempty"`
+}
+
+// MoveDeleter relocates results into a destination directory, mirroring
+// their paths relative to the search root, and records every move in a manifest.
+// The manifest is created before the first move, so nothing is moved unrecorded.
+type MoveDeleter struct {
+	Dest  string   // quarantine directory
+	Root  string   // paths inside Dest are relative to this directory
+	Roots []string // search roots, which Dest may not be inside
+
+	manifest     *os.File
+	manifestPath string
+	entries      int
+}
+
+// Prepare creates the manifest, so a run that cannot record its moves stops
+// before anything is moved. A Dest inside a search root is refused, since the
+// search would find what was already moved there.
+func (d *MoveDeleter) Prepare(results []Result) ([]Result, error) {
+	if err := CheckDestination("move destination", d.Dest, d.Roots); err != nil {
+		return nil, err
+	}
+	if err := d.openManifest(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (d *MoveDeleter) Delete(result Result) error {
+	if err := d.openManifest(); err != nil {
+		return err
+	}
+	dest, err := filepath.Abs(d.Dest)
+	if err != nil {
+		return err
+	}
+	target, err := freePath(filepath.Join(dest, RelativeToRoot(d.Root, result.Path)))
+	if err != nil {
+		return err
+	}
+
+	if err := MovePath(result.Path, target); err != nil {
+		return err
+	}
+	return d.record(ManifestEntry{From: result.Path, To: target, IsDir: result.IsDir})
+}
+
+func (d *MoveDeleter) Verb() string    { return "Moved" }
+func (d *MoveDeleter) Heading() string { return "Moving to " + d.Dest + "..." }
+
+// openManifest creates the manifest in Dest under a name no other run can
+// hold: the time to the nanosecond plus a random suffix, opened exclusively
+func (d *MoveDeleter) openManifest() error {
+	if d.manifest != nil {
+		return nil
+	}
+	if err := os.MkdirAll(d.Dest, 0755); err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			return fmt.Errorf("manifest: %w", err)
+		}
+		name := fmt.Sprintf(".delf-manifest-%s-%x.jsonl", time.Now().Format("20060102T150405.000000000"), suffix)
+		f, err := os.OpenFile(filepath.Join(d.Dest, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("manifest: %w", err)
+		}
+		d.manifest = f
+		d.manifestPath = f.Name()
+		return nil
+	}
+	return fmt.Errorf("manifest: no free name in %s", d.Dest)
+}
+
+// record appends an entry to the manifest
+func (d *MoveDeleter) record(entry ManifestEntry) error {
+	entry.Time = time.Now()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := d.manifest.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("moved to %s but manifest failed: %w", entry.To, err)
+	}
+	d.entries++
+	return nil
+}
+
+// ManifestPath returns the manifest written so far, or "" when nothing was moved
+func (d *MoveDeleter) ManifestPath() string {
+	if d.entries == 0 {
+		return ""
+	}
+	return d.manifestPath
+}
+
+// Close finishes the manifest, removing it when nothing was moved
+func (d *MoveDeleter) Close() error {
+	if d.manifest == nil {
+		return nil
+	}
+	err := d.manifest.Close()
+	d.manifest = nil
+	if d.entries == 0 {
+		os.Remove(d.manifestPath)
+	}
+	return err
+}
+
+// freePath returns path, or the first "name.N.ext" variant that does not exist yet
+func freePath(path string) (string, error) {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path, nil
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; i < 10000; i++ {
+		candidate := fmt.Sprintf("%s.%d%s", base, i, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free name for %s", path)
+}
+
+// MovePath renames src to dst, falling back to copy, verify and delete
+// when they are on different filesystems
+func MovePath(src, dst string) error {
+	if err := ensureParent(dst); err != nil {
+		return err
+	}
+
+	err := os.Rename(src, dst)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return fmt.Errorf("cross-device copy: %w", err)
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree copies a file or directory tree, keeping modes and mtimes,
+// and verifies every copied file against the source
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()|0700); err != nil {
+				return err
+			}
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			if err := copyVerified(path, target, info.Mode().Perm()); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: cannot copy special file", path)
+		}
+		return os.Chtimes(target, info.ModTime(), info.ModTime())
+	})
+}
+
+// copyVerified copies one file and re-reads the copy to compare checksums
+func copyVerified(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(out, io.TeeReader(in, h)); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	copied, err := HashFile(dst)
+	if err != nil {
+		return err
+	}
+	if copied != fmt.Sprintf("%x", h.Sum(nil)) {
+		return fmt.Errorf("%s: copy does not match the original", dst)
+	}
+	return nil
+}
+
+// ReadManifest loads every entry of a move manifest
+func ReadManifest(path string) ([]ManifestEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ManifestEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry ManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// RestoreEntry moves one quarantined path back, never overwriting anything
+func RestoreEntry(entry ManifestEntry, dryRun bool) error {
+	if _, err := os.Lstat(entry.To); err != nil {
+		return fmt.Errorf("%s is gone", entry.To)
+	}
+	if _, err := os.Lstat(entry.From); err == nil {
+		return fmt.Errorf("something already exists there")
+	}
+	if dryRun {
+		return nil
+	}
+	return MovePath(entry.To, entry.From)
+}
+
+// HashFile returns the hex SHA-256 of a file's contents
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
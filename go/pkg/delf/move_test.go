@@ -0,0 +1,122 @@
+package delf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// touch creates a file with some content, making parent directories
+func touch(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMoveDeletersShareDest(t *testing.T) {
+	root := t.TempDir()
+	dest := t.TempDir()
+	a := filepath.Join(root, "a.tmp")
+	b := filepath.Join(root, "b.tmp")
+	touch(t, a)
+	touch(t, b)
+
+	// Both runs start within the same second and move into the same directory
+	first := &MoveDeleter{Dest: dest, Root: root}
+	second := &MoveDeleter{Dest: dest, Root: root}
+	for _, d := range []*MoveDeleter{first, second} {
+		if _, err := d.Prepare(nil); err != nil {
+			t.Fatalf("Prepare: %v", err)
+		}
+	}
+	if err := first.Delete(Result{Path: a}); err != nil {
+		t.Fatalf("first move: %v", err)
+	}
+	if err := second.Delete(Result{Path: b}); err != nil {
+		t.Fatalf("second move: %v", err)
+	}
+	for _, d := range []*MoveDeleter{first, second} {
+		if err := d.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	if first.ManifestPath() == second.ManifestPath() {
+		t.Fatalf("both runs wrote %s", first.ManifestPath())
+	}
+	for _, tc := range []struct {
+		d    *MoveDeleter
+		from string
+	}{{first, a}, {second, b}} {
+		entries, err := ReadManifest(tc.d.ManifestPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].From != tc.from {
+			t.Fatalf("manifest %s = %+v, want one entry from %s", tc.d.ManifestPath(), entries, tc.from)
+		}
+		if err := RestoreEntry(entries[0], false); err != nil {
+			t.Fatalf("restore %s: %v", tc.from, err)
+		}
+		if _, err := os.Stat(tc.from); err != nil {
+			t.Fatalf("%s not restored: %v", tc.from, err)
+		}
+	}
+}
+
+func TestMoveDeleterNoManifestNoMove(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.tmp")
+	touch(t, a)
+
+	// A file where the destination directory should be
+	dest := filepath.Join(t.TempDir(), "dest")
+	touch(t, dest)
+
+	d := &MoveDeleter{Dest: dest, Root: root}
+	if _, err := d.Prepare([]Result{{Path: a}}); err == nil {
+		t.Fatal("Prepare succeeded without a usable manifest")
+	}
+	if err := d.Delete(Result{Path: a}); err == nil {
+		t.Fatal("Delete succeeded without a usable manifest")
+	}
+	if _, err := os.Stat(a); err != nil {
+		t.Fatalf("%s was moved without a manifest: %v", a, err)
+	}
+}
+
+func TestMoveDeleterInsideRoot(t *testing.T) {
+	root := t.TempDir()
+	a := filepath.Join(root, "a.log")
+	touch(t, a)
+
+	dest := filepath.Join(root, "q")
+	d := &MoveDeleter{Dest: dest, Root: root, Roots: []string{root}}
+	if _, err := d.Prepare([]Result{{Path: a}}); err == nil {
+		t.Fatal("Prepare accepted a destination inside the search root")
+	}
+	if exists(dest) {
+		t.Fatal("Prepare created the destination inside the search root")
+	}
+}
+
+func TestMoveDeleterRemovesUnusedManifest(t *testing.T) {
+	dest := t.TempDir()
+	d := &MoveDeleter{Dest: dest, Root: t.TempDir()}
+	if _, err := d.Prepare(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if d.ManifestPath() != "" {
+		t.Fatalf("ManifestPath = %q after moving nothing", d.ManifestPath())
+	}
+	if entries, _ := os.ReadDir(dest); len(entries) != 0 {
+		t.Fatalf("dest holds %v after moving nothing", entries)
+	}
+}
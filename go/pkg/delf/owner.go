@@ -0,0 +1,197 @@
+package delf
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Ownership matches entries by owning user and group
+type Ownership struct {
+	UID, GID int  // -1 matches any
+	NoUser   bool // owner UID has no account
+}
+
+// PermMatch selects how Permission.Bits are compared
+type PermMatch byte
+
+const (
+	PermExact PermMatch = '=' // mode equals the bits
+	PermAll   PermMatch = '-' // every bit is set
+	PermAny   PermMatch = '/' // at least one bit is set
+)
+
+// Permission matches entries by Unix permission bits
+type Permission struct {
+	Bits  uint32
+	Match PermMatch
+}
+
+// LookupUID resolves a user name to its numeric UID
+func LookupUID(name string) (int, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, err
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return 0, fmt.Errorf("%s has no numeric UID", name)
+	}
+	return uid, nil
+}
+
+// LookupGID resolves a group name to its numeric GID
+func LookupGID(name string) (int, error) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, err
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return 0, fmt.Errorf("%s has no numeric GID", name)
+	}
+	return gid, nil
+}
+
+// FdOwner translates the user and group into fd's --owner value
+func (o Ownership) FdOwner() string {
+	if o.UID < 0 && o.GID < 0 {
+		return ""
+	}
+	owner := ""
+	if o.UID >= 0 {
+		owner = strconv.Itoa(o.UID)
+	}
+	if o.GID >= 0 {
+		owner += ":" + strconv.Itoa(o.GID)
+	}
+	return owner
+}
+
+// match checks the owner of one entry
+func (o Ownership) match(info os.FileInfo) bool {
+	uid, gid, ok := fileOwner(info)
+	if !ok {
+		return false
+	}
+	if o.UID >= 0 && uid != o.UID || o.GID >= 0 && gid != o.GID {
+		return false
+	}
+	return !o.NoUser || !userExists(uid)
+}
+
+// match checks the permission bits of one entry
+func (p Permission) match(info os.FileInfo) bool {
+	mode := permBits(info.Mode())
+	switch p.Match {
+	case PermAll:
+		return mode&p.Bits == p.Bits
+	case PermAny:
+		return p.Bits == 0 || mode&p.Bits != 0
+	}
+	return mode == p.Bits
+}
+
+var (
+	knownUsers   = make(map[int]bool)
+	knownUsersMu sync.Mutex
+)
+
+// userExists reports whether a UID has an account, caching lookups
+func userExists(uid int) bool {
+	knownUsersMu.Lock()
+	defer knownUsersMu.Unlock()
+
+	exists, ok := knownUsers[uid]
+	if !ok {
+		_, err := user.LookupId(strconv.Itoa(uid))
+		exists = err == nil
+		knownUsers[uid] = exists
+	}
+	return exists
+}
+
+// permBits converts a FileMode into Unix permission bits, including setuid, setgid and sticky
+func permBits(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return bits
+}
+
+// ParsePerm parses a find(1)-style mode: "644" matches exactly, "-o+w" needs
+// all listed bits and "/111" any of them. Modes are octal or symbolic (u+x,g+w,o=r).
+func ParsePerm(value string) (Permission, error) {
+	p := Permission{Match: PermExact}
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "/") {
+		p.Match, value = PermMatch(value[0]), value[1:]
+	}
+	if value == "" {
+		return p, fmt.Errorf("empty mode")
+	}
+
+	if n, err := strconv.ParseUint(value, 8, 32); err == nil {
+		if n > 07777 {
+			return p, fmt.Errorf("invalid mode: %s", value)
+		}
+		p.Bits = uint32(n)
+		return p, nil
+	}
+
+	for _, clause := range strings.Split(value, ",") {
+		i := strings.IndexAny(clause, "+=")
+		if i < 0 {
+			return p, fmt.Errorf("invalid mode: %s (e.g. 644, o+w, u+x,g+x)", clause)
+		}
+		who, what := clause[:i], clause[i+1:]
+		if who == "" {
+			who = "a"
+		}
+
+		var whoMask uint32
+		for _, c := range who {
+			switch c {
+			case 'u':
+				whoMask |= 04700
+			case 'g':
+				whoMask |= 02070
+			case 'o':
+				whoMask |= 01007
+			case 'a':
+				whoMask |= 07777
+			default:
+				return p, fmt.Errorf("invalid mode: %s (who must be u, g, o or a)", clause)
+			}
+		}
+
+		var whatBits uint32
+		for _, c := range what {
+			switch c {
+			case 'r':
+				whatBits |= 0444
+			case 'w':
+				whatBits |= 0222
+			case 'x':
+				whatBits |= 0111
+			case 's':
+				whatBits |= 06000
+			case 't':
+				whatBits |= 01000
+			default:
+				return p, fmt.Errorf("invalid mode: %s (permissions must be r, w, x, s or t)", clause)
+			}
+		}
+		p.Bits |= whoMask & whatBits
+	}
+	return p, nil
+}
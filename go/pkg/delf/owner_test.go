@@ -0,0 +1,43 @@
+package delf
+
+import "testing"
+
+func TestParsePerm(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Permission
+	}{
+		{"644", Permission{Bits: 0644, Match: PermExact}},
+		{"0755", Permission{Bits: 0755, Match: PermExact}},
+		{"4755", Permission{Bits: 04755, Match: PermExact}},
+		{"-o+w", Permission{Bits: 0002, Match: PermAll}},
+		{"/111", Permission{Bits: 0111, Match: PermAny}},
+		{"-022", Permission{Bits: 0022, Match: PermAll}},
+		{"u+x", Permission{Bits: 0100, Match: PermExact}},
+		{"u+x,g+w", Permission{Bits: 0120, Match: PermExact}},
+		{"o=r", Permission{Bits: 0004, Match: PermExact}},
+		{"ug+rw", Permission{Bits: 0660, Match: PermExact}},
+		{"+x", Permission{Bits: 0111, Match: PermExact}},
+		{"a+r", Permission{Bits: 0444, Match: PermExact}},
+		{"u+s", Permission{Bits: 04000, Match: PermExact}},
+		{"g+s", Permission{Bits: 02000, Match: PermExact}},
+		{"o+t", Permission{Bits: 01000, Match: PermExact}},
+		{"/u+w,g+w", Permission{Bits: 0220, Match: PermAny}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePerm(tt.in)
+		if err != nil {
+			t.Errorf("ParsePerm(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePerm(%q) = %c%o, want %c%o", tt.in, got.Match, got.Bits, tt.want.Match, tt.want.Bits)
+		}
+	}
+
+	for _, in := range []string{"", "-", "/", "10000", "888", "u", "x+r", "u+q", "u+x,", "644,u+x"} {
+		if got, err := ParsePerm(in); err == nil {
+			t.Errorf("ParsePerm(%q) = %c%o, want an error", in, got.Match, got.Bits)
+		}
+	}
+}
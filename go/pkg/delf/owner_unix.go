@@ -0,0 +1,20 @@
+//go:build !windows
+
+package delf
+
+import (
+	"os"
+	"syscall"
+)
+
+// OwnershipSupported reports whether Ownership filters work on this platform
+const OwnershipSupported = true
+
+// fileOwner returns the UID and GID from the stat data
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return int(st.Uid), int(st.Gid), true
+}
@@ -0,0 +1,12 @@
+package delf
+
+import "os"
+
+// OwnershipSupported is false because Windows files are owned by SIDs, which
+// numeric UIDs and GIDs cannot express
+const OwnershipSupported = false
+
+// fileOwner is not available on Windows
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
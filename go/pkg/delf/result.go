@@ -0,0 +1,119 @@
+package delf
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Result is one matched file or directory
+type Result struct {
+	Path     string
+	Root     string // search root the match was found under
+	Category Category
+	IsDir    bool
+	Git      GitStatus
+	Holders  []Holder
+	Nested   int // matches below this directory folded into it
+}
+
+// CollapseNested drops results that lie inside another matched directory, since
+// deleting the directory removes them too. Each remaining root counts the matches
+// folded into it and takes on their worst safety category and git status.
+func CollapseNested(results []Result) []Result {
+	dirs := make(map[string]int)
+	for i, r := range results {
+		if r.IsDir {
+			dirs[filepath.Clean(r.Path)] = i
+		}
+	}
+
+	rootOf := make([]int, len(results))
+	for i, r := range results {
+		rootOf[i] = -1
+		for dir := filepath.Dir(filepath.Clean(r.Path)); ; dir = filepath.Dir(dir) {
+			if j, ok := dirs[dir]; ok {
+				rootOf[i] = j
+			}
+			if parent := filepath.Dir(dir); parent == dir {
+				break
+			}
+		}
+	}
+
+	for i, r := range results {
+		j := rootOf[i]
+		if j < 0 {
+			continue
+		}
+		root := &results[j]
+		root.Nested++
+		if r.Category > root.Category {
+			root.Category = r.Category
+		}
+		if r.Git > root.Git {
+			root.Git = r.Git
+		}
+	}
+
+	var roots []Result
+	for i, r := range results {
+		if rootOf[i] < 0 {
+			roots = append(roots, r)
+		}
+	}
+	return roots
+}
+
+// TotalSize sums the sizes of all results
+func TotalSize(results []Result) int64 {
+	var total int64
+	for _, result := range results {
+		total += PathSize(result.Path)
+	}
+	return total
+}
+
+// PathSize returns the size of a file, or the summed size of everything inside a directory
+func PathSize(path string) int64 {
+	return WalkSizes(path, nil, nil)
+}
+
+// WalkSizes sizes path like PathSize, calling file for every file it counts and
+// dir for every directory below path; dir returning false leaves that directory
+// out. Either callback may be nil. Unreadable entries are not counted.
+func WalkSizes(path string, dir func(path string, info os.FileInfo) bool, file func(path string, info os.FileInfo)) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	if !info.IsDir() {
+		if file != nil {
+			file(path, info)
+		}
+		return info.Size()
+	}
+
+	// Sum up directory contents
+	var total int64
+	filepath.WalkDir(path, func(entry string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if entry != path && dir != nil && !dir(entry, info) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		total += info.Size()
+		if file != nil {
+			file(entry, info)
+		}
+		return nil
+	})
+	return total
+}
@@ -0,0 +1,109 @@
+package delf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCollapseNested(t *testing.T) {
+	root := filepath.Join(string(filepath.Separator), "src")
+	build := filepath.Join(root, "build")
+	cache := filepath.Join(build, "cache")
+	results := []Result{
+		{Path: build, IsDir: true, Category: CategorySafe, Git: GitIgnored},
+		{Path: filepath.Join(build, "a.o"), Category: CategorySafe, Git: GitIgnored},
+		{Path: cache, IsDir: true, Category: CategoryWarning, Git: GitUntracked},
+		{Path: filepath.Join(cache, "keys.pem"), Category: CategoryCritical, Git: GitTrackedModified},
+		{Path: filepath.Join(root, "build.log"), Category: CategoryWarning, Git: GitTrackedClean},
+	}
+
+	got := CollapseNested(results)
+	var paths []string
+	for _, r := range got {
+		paths = append(paths, r.Path)
+	}
+	want := []string{build, filepath.Join(root, "build.log")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("CollapseNested kept %q, want %q", paths, want)
+	}
+
+	top := got[0]
+	if top.Nested != 3 {
+		t.Errorf("Nested = %d, want 3", top.Nested)
+	}
+	if top.Category != CategoryCritical {
+		t.Errorf("Category = %v, want the worst nested category %v", top.Category, CategoryCritical)
+	}
+	if top.Git != GitTrackedModified {
+		t.Errorf("Git = %v, want the most protected nested status %v", top.Git, GitTrackedModified)
+	}
+
+	// A sibling with a shared name prefix is not nested
+	if other := got[1]; other.Nested != 0 || other.Category != CategoryWarning || other.Git != GitTrackedClean {
+		t.Errorf("build.log was changed: %+v", other)
+	}
+}
+
+func TestCollapseNestedKeepsStatusOfTopLevel(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "repo", "vendor")
+	results := []Result{
+		{Path: dir, IsDir: true, Category: CategoryCritical, Git: GitTrackedClean},
+		{Path: filepath.Join(dir, "x.tmp"), Category: CategorySafe, Git: GitIgnored},
+	}
+	got := CollapseNested(results)
+	if len(got) != 1 || got[0].Category != CategoryCritical || got[0].Git != GitTrackedClean {
+		t.Fatalf("milder nested matches lowered the top-level match: %+v", got)
+	}
+}
+
+func TestWalkSizes(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]int{"a": 10, "sub/b": 20, "sub/skip/c": 40, "sub/deep/d": 80}
+	for name, size := range files {
+		path := filepath.Join(root, name)
+		touch(t, path)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := PathSize(root); got != 150 {
+		t.Fatalf("PathSize(root) = %d, want 150", got)
+	}
+	if got := PathSize(filepath.Join(root, "sub", "b")); got != 20 {
+		t.Fatalf("PathSize(file) = %d, want 20", got)
+	}
+	if got := PathSize(filepath.Join(root, "missing")); got != 0 {
+		t.Fatalf("PathSize(missing) = %d, want 0", got)
+	}
+
+	var dirs, seen []string
+	total := WalkSizes(root, func(path string, info os.FileInfo) bool {
+		dirs = append(dirs, filepath.ToSlash(strings.TrimPrefix(path, root)))
+		return filepath.Base(path) != "skip"
+	}, func(path string, info os.FileInfo) {
+		seen = append(seen, filepath.ToSlash(strings.TrimPrefix(path, root)))
+	})
+	sort.Strings(dirs)
+	sort.Strings(seen)
+	if total != 110 {
+		t.Errorf("WalkSizes = %d, want 110 without the skipped directory", total)
+	}
+	if want := []string{"/sub", "/sub/deep", "/sub/skip"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("dir callback saw %q, want %q", dirs, want)
+	}
+	if want := []string{"/a", "/sub/b", "/sub/deep/d"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("file callback saw %q, want %q", seen, want)
+	}
+
+	// A file path reports itself
+	seen = nil
+	WalkSizes(filepath.Join(root, "a"), nil, func(path string, info os.FileInfo) { seen = append(seen, path) })
+	if len(seen) != 1 {
+		t.Errorf("file callback for a file path saw %q", seen)
+	}
+}
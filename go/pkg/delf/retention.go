@@ -0,0 +1,165 @@
+package delf
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Retention holds matches back from deletion by their age rank, for rules such
+// as "delete old backups but always keep the newest 5" or "keep one build per
+// branch". Each rule picks matches to keep on its own and a match kept by any
+// rule is not deleted. The zero value keeps nothing.
+//
+// Retention ranks only the results it is given, so it applies after every
+// search filter: with an age filter, KeepNewest keeps the newest of the old
+// matches, not the newest files overall.
+type Retention struct {
+	KeepNewest  int // keep the N most recent matches
+	KeepOldest  int // keep the N oldest matches
+	KeepDaily   int // keep the newest match of each of the last N days that have one
+	KeepWeekly  int // the same per ISO week
+	KeepMonthly int // the same per calendar month
+
+	// GroupBy applies the rules to each group of matches separately. Matches
+	// are grouped by the capture groups of GroupBy in their path, or by the
+	// whole match when it has none. Matches it does not match are kept.
+	GroupBy *regexp.Regexp
+
+	TimeField string // timestamp that orders matches: mtime (default), atime, ctime or btime
+}
+
+// Active reports whether any retention rule is set
+func (r Retention) Active() bool {
+	return r.KeepNewest > 0 || r.KeepOldest > 0 || r.KeepDaily > 0 || r.KeepWeekly > 0 || r.KeepMonthly > 0
+}
+
+// String describes the rules, e.g. "newest 5, daily 7 per group"
+func (r Retention) String() string {
+	var rules []string
+	for _, rule := range []struct {
+		name string
+		n    int
+	}{
+		{"newest", r.KeepNewest}, {"oldest", r.KeepOldest},
+		{"daily", r.KeepDaily}, {"weekly", r.KeepWeekly}, {"monthly", r.KeepMonthly},
+	} {
+		if rule.n > 0 {
+			rules = append(rules, fmt.Sprintf("%s %d", rule.name, rule.n))
+		}
+	}
+	s := strings.Join(rules, ", ")
+	if r.GroupBy != nil {
+		s += " per group"
+	}
+	return s
+}
+
+// dated is a result with the timestamp retention ranks it by
+type dated struct {
+	index int
+	time  time.Time
+}
+
+// Apply splits results into those to delete and those retained, keeping the
+// order of results in both. Entries whose timestamp cannot be read are
+// retained, since their age is unknown.
+func (r Retention) Apply(results []Result) (remove, keep []Result) {
+	if !r.Active() {
+		return results, nil
+	}
+
+	field := r.TimeField
+	if field == "" {
+		field = "mtime"
+	}
+
+	// Group the entries whose timestamp is known
+	retained := make([]bool, len(results))
+	groups := make(map[string][]dated)
+	for i, result := range results {
+		key, ok := r.groupKey(result.Path)
+		if !ok {
+			retained[i] = true
+			continue
+		}
+		info, err := os.Lstat(result.Path)
+		if err != nil {
+			retained[i] = true
+			continue
+		}
+		t, err := fileTime(result.Path, info, field)
+		if err != nil {
+			retained[i] = true
+			continue
+		}
+		groups[key] = append(groups[key], dated{i, t})
+	}
+
+	for _, group := range groups {
+		// Newest first; ties keep the search order
+		sort.SliceStable(group, func(a, b int) bool {
+			return group[a].time.After(group[b].time)
+		})
+
+		for n := 0; n < r.KeepNewest && n < len(group); n++ {
+			retained[group[n].index] = true
+		}
+		for n := 0; n < r.KeepOldest && n < len(group); n++ {
+			retained[group[len(group)-1-n].index] = true
+		}
+		keepPerPeriod(group, r.KeepDaily, retained, func(t time.Time) string {
+			return t.Format("2006-01-02")
+		})
+		keepPerPeriod(group, r.KeepWeekly, retained, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		})
+		keepPerPeriod(group, r.KeepMonthly, retained, func(t time.Time) string {
+			return t.Format("2006-01")
+		})
+	}
+
+	for i, result := range results {
+		if retained[i] {
+			keep = append(keep, result)
+		} else {
+			remove = append(remove, result)
+		}
+	}
+	return remove, keep
+}
+
+// groupKey returns the group of path, or false when GroupBy does not match it
+func (r Retention) groupKey(path string) (string, bool) {
+	if r.GroupBy == nil {
+		return "", true
+	}
+	match := r.GroupBy.FindStringSubmatch(path)
+	if match == nil {
+		return "", false
+	}
+	if len(match) == 1 {
+		return match[0], true
+	}
+	return strings.Join(match[1:], "\x00"), true
+}
+
+// keepPerPeriod keeps the newest entry of each of the n most recent periods,
+// as backup rotation does. group must be sorted newest first.
+func keepPerPeriod(group []dated, n int, retained []bool, period func(time.Time) string) {
+	last := ""
+	for _, d := range group {
+		if n == 0 {
+			return
+		}
+		if p := period(d.time.Local()); p != last {
+			retained[d.index] = true
+			last = p
+			n--
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package delf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+)
+
+// day returns noon of a local calendar day, away from DST switches
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 12, 0, 0, 0, time.Local)
+}
+
+func TestRetentionApply(t *testing.T) {
+	type file struct {
+		name  string
+		mtime time.Time // zero: the file does not exist
+	}
+	tests := []struct {
+		name      string
+		retention Retention
+		files     []file
+		keep      []string
+	}{
+		{
+			name:      "inactive keeps nothing",
+			retention: Retention{},
+			files:     []file{{"a", day(2025, 1, 1)}, {"b", day(2025, 1, 2)}},
+			keep:      nil,
+		},
+		{
+			name:      "newest",
+			retention: Retention{KeepNewest: 2},
+			files:     []file{{"a", day(2025, 1, 1)}, {"b", day(2025, 1, 3)}, {"c", day(2025, 1, 2)}},
+			keep:      []string{"b", "c"},
+		},
+		{
+			name:      "oldest",
+			retention: Retention{KeepOldest: 1},
+			files:     []file{{"a", day(2025, 1, 2)}, {"b", day(2025, 1, 1)}, {"c", day(2025, 1, 3)}},
+			keep:      []string{"b"},
+		},
+		{
+			name:      "newest and oldest overlap",
+			retention: Retention{KeepNewest: 2, KeepOldest: 2},
+			files:     []file{{"a", day(2025, 1, 1)}, {"b", day(2025, 1, 2)}, {"c", day(2025, 1, 3)}},
+			keep:      []string{"a", "b", "c"},
+		},
+		{
+			name:      "more kept than matches",
+			retention: Retention{KeepNewest: 10},
+			files:     []file{{"a", day(2025, 1, 1)}},
+			keep:      []string{"a"},
+		},
+		{
+			name:      "daily keeps the newest of each day",
+			retention: Retention{KeepDaily: 2},
+			files: []file{
+				{"mon-early", day(2025, 3, 3).Add(-2 * time.Hour)},
+				{"mon-late", day(2025, 3, 3).Add(2 * time.Hour)},
+				{"sun", day(2025, 3, 2)},
+				{"sat", day(2025, 3, 1)},
+			},
+			keep: []string{"mon-late", "sun"},
+		},
+		{
+			name:      "daily skips days without matches",
+			retention: Retention{KeepDaily: 2},
+			files:     []file{{"jan10", day(2025, 1, 10)}, {"jan3", day(2025, 1, 3)}, {"jan1", day(2025, 1, 1)}},
+			keep:      []string{"jan10", "jan3"},
+		},
+		{
+			// 2024-12-30 and 2025-01-05 are both in ISO week 2025-W01
+			name:      "weekly follows ISO weeks across the new year",
+			retention: Retention{KeepWeekly: 2},
+			files: []file{
+				{"2025-01-06", day(2025, 1, 6)},
+				{"2025-01-05", day(2025, 1, 5)},
+				{"2024-12-30", day(2024, 12, 30)},
+				{"2024-12-29", day(2024, 12, 29)},
+			},
+			keep: []string{"2025-01-05", "2025-01-06"},
+		},
+		{
+			name:      "weekly reaches into the previous ISO year",
+			retention: Retention{KeepWeekly: 3},
+			files: []file{
+				{"2025-01-06", day(2025, 1, 6)},
+				{"2025-01-05", day(2025, 1, 5)},
+				{"2024-12-30", day(2024, 12, 30)},
+				{"2024-12-29", day(2024, 12, 29)},
+			},
+			keep: []string{"2024-12-29", "2025-01-05", "2025-01-06"},
+		},
+		{
+			name:      "monthly",
+			retention: Retention{KeepMonthly: 2},
+			files: []file{
+				{"mar", day(2025, 3, 1)},
+				{"feb-late", day(2025, 2, 28)},
+				{"feb-early", day(2025, 2, 1)},
+				{"jan", day(2025, 1, 31)},
+			},
+			keep: []string{"feb-late", "mar"},
+		},
+		{
+			name:      "rules combine",
+			retention: Retention{KeepNewest: 1, KeepMonthly: 2},
+			files: []file{
+				{"mar-2", day(2025, 3, 2)},
+				{"mar-1", day(2025, 3, 1)},
+				{"feb", day(2025, 2, 1)},
+				{"jan", day(2025, 1, 1)},
+			},
+			keep: []string{"feb", "mar-2"},
+		},
+		{
+			name:      "group by capture groups",
+			retention: Retention{KeepNewest: 1, GroupBy: regexp.MustCompile(`(\w+)-\d$`)},
+			files: []file{
+				{"app-1", day(2025, 1, 1)},
+				{"app-2", day(2025, 1, 2)},
+				{"db-1", day(2025, 1, 3)},
+				{"db-2", day(2025, 1, 1)},
+			},
+			keep: []string{"app-2", "db-1"},
+		},
+		{
+			name:      "group by the whole match",
+			retention: Retention{KeepNewest: 1, GroupBy: regexp.MustCompile(`(?:app|db)-`)},
+			files: []file{
+				{"app-1", day(2025, 1, 1)},
+				{"app-2", day(2025, 1, 2)},
+				{"db-1", day(2025, 1, 1)},
+			},
+			keep: []string{"app-2", "db-1"},
+		},
+		{
+			name:      "paths outside every group are kept",
+			retention: Retention{KeepNewest: 1, GroupBy: regexp.MustCompile(`(app)-\d$`)},
+			files: []file{
+				{"app-1", day(2025, 1, 1)},
+				{"app-2", day(2025, 1, 2)},
+				{"notes", day(2020, 1, 1)},
+			},
+			keep: []string{"app-2", "notes"},
+		},
+		{
+			name:      "unreadable timestamps are kept",
+			retention: Retention{KeepNewest: 1},
+			files:     []file{{"a", day(2025, 1, 1)}, {"b", day(2025, 1, 2)}, {"vanished", time.Time{}}},
+			keep:      []string{"b", "vanished"},
+		},
+		{
+			name:      "unknown time field keeps everything",
+			retention: Retention{KeepNewest: 1, TimeField: "bogus"},
+			files:     []file{{"a", day(2025, 1, 1)}, {"b", day(2025, 1, 2)}},
+			keep:      []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var results []Result
+			for _, f := range tt.files {
+				path := filepath.Join(dir, f.name)
+				if !f.mtime.IsZero() {
+					touch(t, path)
+					if err := os.Chtimes(path, f.mtime, f.mtime); err != nil {
+						t.Fatal(err)
+					}
+				}
+				results = append(results, Result{Path: path})
+			}
+
+			remove, keep := tt.retention.Apply(results)
+			if len(remove)+len(keep) != len(results) {
+				t.Fatalf("Apply returned %d + %d of %d results", len(remove), len(keep), len(results))
+			}
+			var kept []string
+			for _, result := range keep {
+				kept = append(kept, filepath.Base(result.Path))
+			}
+			sort.Strings(kept)
+			if !reflect.DeepEqual(kept, tt.keep) {
+				t.Fatalf("kept %q, want %q", kept, tt.keep)
+			}
+		})
+	}
+}
+
+func TestRetentionApplyKeepsOrder(t *testing.T) {
+	dir := t.TempDir()
+	var results []Result
+	for i, name := range []string{"c", "a", "d", "b"} {
+		path := filepath.Join(dir, name)
+		touch(t, path)
+		mtime := day(2025, 1, 1+i)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, Result{Path: path})
+	}
+
+	remove, keep := Retention{KeepNewest: 2}.Apply(results)
+	if !reflect.DeepEqual(remove, results[:2]) || !reflect.DeepEqual(keep, results[2:]) {
+		t.Fatalf("Apply reordered results: remove %v, keep %v", remove, keep)
+	}
+}
@@ -0,0 +1,172 @@
+package delf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Category represents the safety category of a file
+type Category int
+
+const (
+	CategorySafe Category = iota
+	CategoryWarning
+	CategoryCritical
+)
+
+// String returns a short label for the category
+func (c Category) String() string {
+	switch c {
+	case CategoryWarning:
+		return "warning"
+	case CategoryCritical:
+		return "critical"
+	default:
+		return "safe"
+	}
+}
+
+// CriticalSystemPaths are paths that should NEVER be deleted
+var CriticalSystemPaths = []string{
+	`C:\Windows`,
+	`C:\Windows\System32`,
+	`C:\Windows\SysWOW64`,
+	`C:\Program Files`,
+	`C:\Program Files (x86)`,
+	`C:\ProgramData`,
+	`C:\Users\Default`,
+	`C:\Users\Public`,
+	`C:\Recovery`,
+	`C:\Boot`,
+}
+
+// WarningSystemPaths are paths that require extra caution
+var WarningSystemPaths = []string{
+	`C:\Users`,
+	`C:\Temp`,
+}
+
+// AutoExcludePatterns are patterns excluded by default
+var AutoExcludePatterns = []string{
+	"node_modules",
+	".git",
+	".npm",
+	".cache",
+	".vscode",
+	".idea",
+}
+
+// init adds environment variable paths to critical lists
+func init() {
+	// Add environment variable paths
+	if systemRoot := os.Getenv("SystemRoot"); systemRoot != "" {
+		CriticalSystemPaths = append(CriticalSystemPaths, systemRoot)
+	}
+	if winDir := os.Getenv("windir"); winDir != "" {
+		CriticalSystemPaths = append(CriticalSystemPaths, winDir)
+	}
+	if temp := os.Getenv("TEMP"); temp != "" {
+		WarningSystemPaths = append(WarningSystemPaths, temp)
+	}
+	if tmp := os.Getenv("TMP"); tmp != "" {
+		WarningSystemPaths = append(WarningSystemPaths, tmp)
+	}
+}
+
+// Classifier rates paths by how dangerous they are to delete
+type Classifier struct {
+	Critical []string // paths (and everything below them) that are critical
+	Warning  []string // paths (and everything below them) that need extra caution
+}
+
+// NewClassifier returns a classifier using the built-in system path lists
+func NewClassifier() *Classifier {
+	return &Classifier{
+		Critical: append([]string(nil), CriticalSystemPaths...),
+		Warning:  append([]string(nil), WarningSystemPaths...),
+	}
+}
+
+// Classify determines the safety category of a path
+func (c *Classifier) Classify(filePath string) Category {
+	if hasPathPrefix(filePath, c.Critical) {
+		return CategoryCritical
+	}
+	if hasPathPrefix(filePath, c.Warning) {
+		return CategoryWarning
+	}
+	return CategorySafe
+}
+
+// hasPathPrefix checks case-insensitively whether a path starts with any of prefixes
+func hasPathPrefix(filePath string, prefixes []string) bool {
+	normalizedPath := strings.ToLower(filepath.Clean(filePath))
+
+	for _, prefix := range prefixes {
+		normalizedPrefix := strings.ToLower(filepath.Clean(prefix))
+		if strings.HasPrefix(normalizedPath, normalizedPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAutoExcluded checks if a path matches auto-exclude patterns
+func IsAutoExcluded(filePath string) bool {
+	normalizedPath := strings.ToLower(filePath)
+
+	for _, pattern := range AutoExcludePatterns {
+		// Check if any path component matches the pattern
+		if strings.Contains(normalizedPath, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmptyDirectory checks if a directory is empty
+func IsEmptyDirectory(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return false, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return false, err
+	}
+	return len(entries) == 0, nil
+}
+
+// MatchesExclusionPattern checks if a path matches any exclusion pattern
+func MatchesExclusionPattern(filePath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		// Handle wildcard patterns
+		if strings.Contains(pattern, "*") {
+			matched, err := filepath.Match(pattern, filepath.Base(filePath))
+			if err == nil && matched {
+				return true
+			}
+			// Also check full path
+			matched, err = filepath.Match(pattern, filePath)
+			if err == nil && matched {
+				return true
+			}
+		} else {
+			// Simple substring match
+			if strings.Contains(strings.ToLower(filePath), strings.ToLower(pattern)) {
+				return true
+			}
+		}
+	}
+	return false
+}
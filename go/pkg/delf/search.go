@@ -0,0 +1,815 @@
+package delf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SearchOptions configures a Searcher
+type SearchOptions struct {
+	Pattern    string   // glob matched against entry names; empty matches everything
+	Root       string   // directory to search
+	Roots      []string // several directories to search concurrently, instead of Root
+	Type       string   // "f" for files, "d" for directories, empty for both
+	IgnoreCase bool
+	All        bool // don't skip AutoExcludePatterns
+	EmptyDirs  bool // find empty directories instead of matching Pattern
+
+	MaxDepth int  // descend at most this many levels below Root (0 = unlimited)
+	MinDepth int  // only match entries at least this deep
+	Prune    bool // don't descend into matched directories
+	Limit    int  // stop after this many results (0 = unlimited)
+
+	Filter     Filter
+	Classifier *Classifier // nil uses NewClassifier()
+	NoFd       bool        // always use the built-in walker
+	Strict     bool        // end the search at the first entry that cannot be read or checked
+
+	// Paths, when set, replaces the directory walk: candidate paths are read from
+	// it, one per line or NUL-terminated with NulSeparated. Relative paths are
+	// taken from Root, and every other option except the depth limits applies.
+	Paths        io.Reader
+	NulSeparated bool
+
+	// Sink receives ResultFound, Skipped, Error and Progress events; nil discards them
+	Sink Sink
+}
+
+// progressInterval is how many examined entries pass between Progress events
+const progressInterval = 1000
+
+// Searcher finds entries below one or more root directories
+type Searcher struct {
+	opts    SearchOptions
+	roots   []string
+	usingFd bool
+	err     error
+
+	mu       sync.Mutex // guards the fields below, shared by the per-root searches
+	count    int
+	examined int
+	errs     []*EntryError
+
+	sinkMu sync.Mutex // lets the per-root searches report one event at a time
+}
+
+// EntryError is an entry the search could not read or check; it never matches
+type EntryError struct {
+	Path string
+	Dir  bool // a directory whose contents were not searched
+	Err  error
+}
+
+func (e *EntryError) Error() string {
+	// Errors from the os package already name the path
+	var pathErr *fs.PathError
+	if errors.As(e.Err, &pathErr) {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// HasFd checks if fd is available in PATH
+func HasFd() bool {
+	_, err := exec.LookPath("fd")
+	return err == nil
+}
+
+// NewSearcher prepares a search, picking fd when it is installed
+func NewSearcher(opts SearchOptions) *Searcher {
+	roots := opts.Roots
+	if len(roots) == 0 {
+		roots = []string{opts.Root}
+	}
+	roots = DedupeRoots(roots)
+	opts.Root = roots[0]
+
+	if opts.Classifier == nil {
+		opts.Classifier = NewClassifier()
+	}
+	if opts.Sink == nil {
+		opts.Sink = Discard
+	}
+	return &Searcher{opts: opts, roots: roots, usingFd: !opts.NoFd && !opts.EmptyDirs && opts.Paths == nil && HasFd()}
+}
+
+// DedupeRoots makes roots absolute and drops those that repeat, or lie inside,
+// another root, so no entry is searched twice. Order is otherwise kept; an empty
+// root means the current directory.
+func DedupeRoots(roots []string) []string {
+	type root struct{ path, key string }
+	var candidates []root
+	for _, path := range roots {
+		if path == "" {
+			path = "."
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		key := path
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			key = resolved
+		}
+		candidates = append(candidates, root{path, key})
+	}
+
+	var kept []string
+	for i, c := range candidates {
+		covered := false
+		for j, other := range candidates {
+			if i == j {
+				continue
+			}
+			// Keep the first of two equal roots, and the outer of two nested ones
+			if c.key == other.key && j < i || c.key != other.key && IsInside(c.key, other.key) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, c.path)
+		}
+	}
+	return kept
+}
+
+// CommonRoot returns the deepest directory holding every one of roots, which
+// suits DeleteOptions.Root when several roots were searched
+func CommonRoot(roots []string) string {
+	var common string
+	for _, root := range DedupeRoots(roots) {
+		if common == "" {
+			common = root
+			continue
+		}
+		for common != filepath.Dir(common) && !IsInside(root, common) {
+			common = filepath.Dir(common)
+		}
+	}
+	return common
+}
+
+// IsInside reports whether path is dir or lies below it
+func IsInside(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// UsingFd reports whether the search runs through fd
+func (s *Searcher) UsingFd() bool {
+	return s.usingFd
+}
+
+// Root returns the first absolute directory being searched
+func (s *Searcher) Root() string {
+	return s.roots[0]
+}
+
+// Roots returns every absolute directory being searched, after DedupeRoots
+func (s *Searcher) Roots() []string {
+	return s.roots
+}
+
+// Search streams matches on the returned channel, which is closed when the
+// search ends or ctx is cancelled. Roots are searched concurrently, so matches
+// from different roots interleave. Drain the channel before calling Err or LimitReached.
+func (s *Searcher) Search(ctx context.Context) <-chan Result {
+	results := make(chan Result, 64)
+	go func() {
+		defer close(results)
+
+		// A path list is read once, whatever the roots
+		if s.opts.Paths != nil {
+			s.err = s.searchList(ctx, s.roots[0], results)
+			return
+		}
+
+		// One root failing under Strict stops the others
+		rootCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		errs := make([]error, len(s.roots))
+		var wg sync.WaitGroup
+		for i, root := range s.roots {
+			wg.Add(1)
+			go func(i int, root string) {
+				defer wg.Done()
+				if errs[i] = s.searchRoot(rootCtx, root, results); errs[i] != nil {
+					cancel()
+				}
+			}(i, root)
+		}
+		wg.Wait()
+
+		s.err = ctx.Err()
+		for _, err := range errs {
+			if err != nil && !errors.Is(err, context.Canceled) {
+				s.err = err
+				break
+			}
+		}
+	}()
+	return results
+}
+
+// searchRoot runs the backend for one root
+func (s *Searcher) searchRoot(ctx context.Context, root string, results chan<- Result) error {
+	switch {
+	case s.opts.EmptyDirs:
+		return s.searchEmptyDirs(ctx, root, results)
+	case s.usingFd:
+		return s.searchWithFd(ctx, root, results)
+	default:
+		return s.searchWithWalk(ctx, root, results)
+	}
+}
+
+// Err returns the error that ended the search early, if any; after
+// cancellation it is the context's error
+func (s *Searcher) Err() error {
+	return s.err
+}
+
+// Errors returns the entries that could not be read or checked, in the order met.
+// With SearchOptions.Strict the first one also ends the search and is returned by Err.
+func (s *Searcher) Errors() []*EntryError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.errs
+}
+
+// LimitReached reports whether the search stopped at SearchOptions.Limit
+func (s *Searcher) LimitReached() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limitReached()
+}
+
+func (s *Searcher) limitReached() bool {
+	return s.opts.Limit > 0 && s.count >= s.opts.Limit
+}
+
+// event reports e to the sink, one event at a time
+func (s *Searcher) event(e Event) {
+	s.sinkMu.Lock()
+	defer s.sinkMu.Unlock()
+	s.opts.Sink.Event(e)
+}
+
+// emit sends one match found under root and reports whether the search should go on
+func (s *Searcher) emit(ctx context.Context, results chan<- Result, root, path string, isDir bool) bool {
+	// Claim a place below the limit first, so concurrent roots never exceed it
+	s.mu.Lock()
+	if s.limitReached() {
+		s.mu.Unlock()
+		return false
+	}
+	s.count++
+	s.mu.Unlock()
+
+	result := Result{
+		Path:     path,
+		Root:     root,
+		Category: s.opts.Classifier.Classify(path),
+		IsDir:    isDir,
+	}
+	// Reported before it is sent, so the consumer's events for it come after
+	s.event(Event{Kind: EventResultFound, Result: result})
+	select {
+	case results <- result:
+	case <-ctx.Done():
+		return false
+	}
+	return !s.LimitReached()
+}
+
+// stop ends a walk after emit said so: with the context's error when it was
+// cancelled, quietly when the result cap was reached
+func (s *Searcher) stop(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return filepath.SkipAll
+}
+
+// examine counts one visited entry, reporting progress every progressInterval entries
+func (s *Searcher) examine() {
+	s.mu.Lock()
+	s.examined++
+	examined := s.examined
+	s.mu.Unlock()
+
+	if examined%progressInterval == 0 {
+		s.event(Event{Kind: EventProgress, Count: examined})
+	}
+}
+
+// skip reports an entry the search passes over
+func (s *Searcher) skip(path, reason string) {
+	s.event(Event{Kind: EventSkipped, Path: path, Reason: reason})
+}
+
+// fail records an entry that could not be read or checked. It returns the
+// error that should end the search under Strict, or nil to carry on.
+func (s *Searcher) fail(path string, dir bool, err error) error {
+	entryErr := &EntryError{Path: path, Dir: dir, Err: err}
+	s.mu.Lock()
+	s.errs = append(s.errs, entryErr)
+	s.mu.Unlock()
+	s.event(Event{Kind: EventError, Path: path, Err: err})
+	if s.opts.Strict {
+		return entryErr
+	}
+	return nil
+}
+
+// filtered applies the metadata filters, recording entries they could not evaluate
+func (s *Searcher) filtered(path string, info os.FileInfo) (bool, error) {
+	ok, err := s.opts.Filter.Match(path, info)
+	if err != nil {
+		return false, s.fail(path, false, err)
+	}
+	return ok, nil
+}
+
+// readError records an error met while reading path below root, skipping what
+// cannot be read. Entries that vanished since their directory was listed are not errors.
+func (s *Searcher) readError(root, path string, d os.DirEntry, err error) error {
+	if path == root {
+		return err
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if strictErr := s.fail(path, d != nil && d.IsDir(), err); strictErr != nil {
+		return strictErr
+	}
+	if d != nil && d.IsDir() {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// searchWithFd uses fd for fast parallel search
+func (s *Searcher) searchWithFd(ctx context.Context, root string, results chan<- Result) error {
+	args := []string{"--color", "never", "--hidden", "--no-ignore"}
+
+	// Type filter
+	if s.opts.Type == "f" {
+		args = append(args, "-t", "f")
+	} else if s.opts.Type == "d" {
+		args = append(args, "-t", "d")
+	}
+
+	// Case sensitivity
+	if s.opts.IgnoreCase {
+		args = append(args, "-i")
+	} else {
+		args = append(args, "-s")
+	}
+
+	// Depth controls
+	if s.opts.MaxDepth > 0 {
+		args = append(args, "--max-depth", strconv.Itoa(s.opts.MaxDepth))
+	}
+	if s.opts.MinDepth > 0 {
+		args = append(args, "--min-depth", strconv.Itoa(s.opts.MinDepth))
+	}
+	// fd prunes a directory as soon as the pattern matches it, before the
+	// metadata filters have had a say, so with filters pruning is done below
+	filter := s.opts.Filter.Active()
+	if s.opts.Prune && !filter {
+		args = append(args, "--prune")
+	}
+
+	// Ownership filter (fd narrows by owner, every filter is still checked below)
+	if owner := s.opts.Filter.Owner; owner != nil && owner.FdOwner() != "" {
+		args = append(args, "--owner", owner.FdOwner())
+	}
+	args = append(args, fdTimeArgs(s.opts.Filter)...)
+
+	// Auto-exclude patterns
+	if !s.opts.All {
+		for _, pattern := range AutoExcludePatterns {
+			args = append(args, "-E", "*"+pattern+"*")
+		}
+	}
+
+	// Glob pattern and path
+	if s.opts.Pattern != "" {
+		args = append(args, "-g", s.opts.Pattern)
+	}
+	args = append(args, root)
+
+	// fd is killed when ctx is cancelled, so it never outlives the search
+	cmd := exec.CommandContext(ctx, "fd", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	// fd reports unreadable directories, and its own failures, on stderr
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	var stopErr error
+	stopped := false
+
+	// fd lists a directory before anything inside it, so entries below an
+	// emitted directory can be dropped as they arrive
+	pruned := make(map[string]bool)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if s.opts.Prune && filter && insideAny(filepath.Clean(line), pruned) {
+			continue
+		}
+		s.examine()
+
+		// Metadata filters are applied here rather than by fd, so every backend agrees
+		if filter {
+			info, err := os.Lstat(line)
+			if err != nil {
+				if stopErr = s.readError(root, line, nil, err); stopErr != nil {
+					stopped = true
+					break
+				}
+				continue
+			}
+			ok, err := s.filtered(line, info)
+			if err != nil {
+				stopErr, stopped = err, true
+				break
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		// Check if it's a directory
+		info, err := os.Stat(line)
+		isDir := err == nil && info.IsDir()
+
+		// Stop fd once the result cap is reached
+		if !s.emit(ctx, results, root, line, isDir) {
+			stopped = true
+			break
+		}
+		if s.opts.Prune && filter && isDir {
+			pruned[filepath.Clean(line)] = true
+		}
+	}
+
+	if stopped {
+		cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if stopped {
+		return stopErr
+	}
+	return s.fdErrors(stderr.String(), waitErr)
+}
+
+// insideAny reports whether path lies below one of dirs
+func insideAny(path string, dirs map[string]bool) bool {
+	if len(dirs) == 0 {
+		return false
+	}
+	for dir := filepath.Dir(path); ; {
+		if dirs[dir] {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// fdTimeArgs lets fd narrow by modification time. fd only knows mtime and
+// whole seconds, so the bounds are widened to the enclosing second and the
+// exact check is still made on every entry fd returns.
+func fdTimeArgs(f Filter) []string {
+	if f.TimeField != "" && f.TimeField != "mtime" {
+		return nil
+	}
+	const layout = "2006-01-02 15:04:05"
+	var args []string
+	if !f.OlderThan.IsZero() {
+		before := f.OlderThan.Truncate(time.Second).Add(time.Second)
+		args = append(args, "--changed-before", before.Local().Format(layout))
+	}
+	if !f.NewerThan.IsZero() {
+		within := f.NewerThan.Truncate(time.Second)
+		args = append(args, "--changed-within", within.Local().Format(layout))
+	}
+	return args
+}
+
+// fdErrors records the entries fd could not read and turns a failed fd run
+// into an error, so a broken fd never looks like an empty result set. fd exits
+// non-zero after read errors, so that exit is only excused by read errors of
+// this run; other roots searched at the same time do not count.
+func (s *Searcher) fdErrors(stderr string, waitErr error) error {
+	var other []string
+	readErrors := 0
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// "[fd error]: /path: Permission denied (os error 13)"
+		msg := strings.TrimSpace(strings.TrimPrefix(line, "[fd error]:"))
+		path, reason, ok := strings.Cut(msg, ": ")
+		if msg == line || !ok || !filepath.IsAbs(path) {
+			other = append(other, line)
+			continue
+		}
+		info, err := os.Lstat(path)
+		readErrors++
+		if strictErr := s.fail(path, err == nil && info.IsDir(), errors.New(reason)); strictErr != nil {
+			return strictErr
+		}
+	}
+
+	if waitErr == nil || readErrors > 0 && len(other) == 0 {
+		return nil
+	}
+	if len(other) > 0 {
+		return fmt.Errorf("fd failed: %s", strings.Join(other, "; "))
+	}
+	return fmt.Errorf("fd failed: %w", waitErr)
+}
+
+// searchWithWalk uses filepath.WalkDir as fallback
+func (s *Searcher) searchWithWalk(ctx context.Context, root string, results chan<- Result) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) (next error) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return s.readError(root, path, d, err)
+		}
+
+		// Skip the root directory itself
+		if path == root {
+			return nil
+		}
+		s.examine()
+
+		// Nothing below MaxDepth is visited: a directory at the limit may
+		// match, but is never read
+		depth := pathDepth(root, path)
+		if s.opts.MaxDepth > 0 && depth >= s.opts.MaxDepth && d.IsDir() {
+			defer skipDirAfter(&next)
+		}
+
+		// Type filter
+		if s.opts.Type == "f" && d.IsDir() {
+			return nil
+		}
+		if s.opts.Type == "d" && !d.IsDir() {
+			return nil
+		}
+
+		// Auto-exclude check
+		if !s.opts.All && IsAutoExcluded(path) {
+			if d.IsDir() {
+				s.skip(path, "auto-excluded")
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Entries above MinDepth are walked through but never matched
+		if depth < s.opts.MinDepth {
+			return nil
+		}
+
+		// Pattern matching (if pattern provided)
+		if s.opts.Pattern != "" {
+			if !MatchPattern(d.Name(), s.opts.Pattern, s.opts.IgnoreCase) {
+				return nil
+			}
+		}
+
+		// Get file info for filtering
+		info, err := d.Info()
+		if err != nil {
+			return s.readError(root, path, d, err)
+		}
+
+		// Metadata filters
+		if ok, err := s.filtered(path, info); !ok {
+			return err
+		}
+
+		if !s.emit(ctx, results, root, path, d.IsDir()) {
+			return s.stop(ctx)
+		}
+
+		// A matched directory is deleted whole, so don't list what is inside it
+		if s.opts.Prune && d.IsDir() {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+}
+
+// skipDirAfter turns a walk callback's nil result into filepath.SkipDir, so a
+// directory at MaxDepth is examined but its contents are never read
+func skipDirAfter(next *error) {
+	if *next == nil {
+		*next = filepath.SkipDir
+	}
+}
+
+// searchEmptyDirs finds empty directories
+func (s *Searcher) searchEmptyDirs(ctx context.Context, root string, results chan<- Result) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) (next error) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return s.readError(root, path, d, err)
+		}
+
+		if path == root {
+			return nil
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+		s.examine()
+
+		depth := pathDepth(root, path)
+		if s.opts.MaxDepth > 0 && depth >= s.opts.MaxDepth {
+			defer skipDirAfter(&next)
+		}
+
+		// Auto-exclude check
+		if !s.opts.All && IsAutoExcluded(path) {
+			s.skip(path, "auto-excluded")
+			return filepath.SkipDir
+		}
+
+		if depth < s.opts.MinDepth {
+			return nil
+		}
+
+		isEmpty, err := IsEmptyDirectory(path)
+		if err != nil {
+			return s.readError(root, path, d, err)
+		}
+		if !isEmpty {
+			return nil
+		}
+
+		if s.opts.Filter.Active() {
+			info, err := d.Info()
+			if err != nil {
+				return s.readError(root, path, d, err)
+			}
+			if ok, err := s.filtered(path, info); !ok {
+				return err
+			}
+		}
+
+		if !s.emit(ctx, results, root, path, true) {
+			return s.stop(ctx)
+		}
+		return nil
+	})
+}
+
+// searchList checks each path read from SearchOptions.Paths instead of walking
+func (s *Searcher) searchList(ctx context.Context, root string, results chan<- Result) error {
+	scanner := bufio.NewScanner(s.opts.Paths)
+	if s.opts.NulSeparated {
+		scanner.Split(scanNul)
+	}
+
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		path := scanner.Text()
+		if !s.opts.NulSeparated {
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path == "" {
+			continue
+		}
+		s.examine()
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		path = filepath.Clean(path)
+
+		// Unlike an entry vanishing mid-walk, a listed path that is missing is reported
+		info, err := os.Lstat(path)
+		if err != nil {
+			if strictErr := s.fail(path, false, err); strictErr != nil {
+				return strictErr
+			}
+			continue
+		}
+
+		// Type filter
+		if s.opts.Type == "f" && info.IsDir() || s.opts.Type == "d" && !info.IsDir() {
+			continue
+		}
+
+		// Auto-exclude check
+		if !s.opts.All && IsAutoExcluded(path) {
+			s.skip(path, "auto-excluded")
+			continue
+		}
+
+		if s.opts.Pattern != "" && !MatchPattern(filepath.Base(path), s.opts.Pattern, s.opts.IgnoreCase) {
+			continue
+		}
+
+		if ok, err := s.filtered(path, info); !ok {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !s.emit(ctx, results, root, path, info.IsDir()) {
+			return ctx.Err()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading path list: %w", err)
+	}
+	return nil
+}
+
+// scanNul is a bufio.SplitFunc for NUL-terminated entries
+func scanNul(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// MatchPattern checks if name matches the glob pattern
+func MatchPattern(name, pattern string, ignoreCase bool) bool {
+	if ignoreCase {
+		name = strings.ToLower(name)
+		pattern = strings.ToLower(pattern)
+	}
+
+	matched, err := filepath.Match(pattern, name)
+	if err != nil {
+		return false
+	}
+	return matched
+}
+
+// pathDepth returns how many levels below root path is; direct children are at depth 1
+func pathDepth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
@@ -0,0 +1,464 @@
+package delf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFdErrors(t *testing.T) {
+	unreadable := filepath.Join(t.TempDir(), "locked")
+	readError := "[fd error]: " + unreadable + ": Permission denied (os error 13)\n"
+	exit := errors.New("exit status 1")
+
+	tests := []struct {
+		name      string
+		stderr    string
+		waitErr   error
+		otherRoot bool // another root already recorded a read error
+		wantErr   bool
+		wantErrs  int
+	}{
+		{"clean run", "", nil, false, false, 0},
+		{"read error explains the exit", readError, exit, false, false, 1},
+		{"exit without output", "", exit, false, true, 0},
+		{"other root's errors do not excuse this exit", "", exit, true, true, 1},
+		{"unknown message", "[fd error]: invalid glob\n", exit, false, true, 0},
+		{"read error and unknown message", readError + "fd: broken\n", exit, false, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSearcher(SearchOptions{Root: t.TempDir()})
+			if tt.otherRoot {
+				s.fail("/elsewhere/locked", true, errors.New("Permission denied"))
+			}
+			err := s.fdErrors(tt.stderr, tt.waitErr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fdErrors() = %v, want error %v", err, tt.wantErr)
+			}
+			if got := len(s.Errors()); got != tt.wantErrs {
+				t.Fatalf("recorded %d entry errors, want %d", got, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestFdTimeArgs(t *testing.T) {
+	older := time.Date(2025, 1, 31, 8, 30, 15, 500e6, time.Local)
+	newer := time.Date(2024, 12, 1, 0, 0, 0, 250e6, time.Local)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"no age filter", Filter{}, nil},
+		{"older than", Filter{OlderThan: older}, []string{"--changed-before", "2025-01-31 08:30:16"}},
+		{"newer than", Filter{NewerThan: newer, TimeField: "mtime"}, []string{"--changed-within", "2024-12-01 00:00:00"}},
+		{"both", Filter{OlderThan: older, NewerThan: newer},
+			[]string{"--changed-before", "2025-01-31 08:30:16", "--changed-within", "2024-12-01 00:00:00"}},
+		{"atime is checked by delf only", Filter{OlderThan: older, TimeField: "atime"}, nil},
+		{"btime is checked by delf only", Filter{NewerThan: newer, TimeField: "btime"}, nil},
+	}
+	for _, tt := range tests {
+		if got := fdTimeArgs(tt.filter); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: fdTimeArgs = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// fakeFd puts an fd on PATH that records its arguments and prints lines
+func fakeFd(t *testing.T, lines []string) (argsFile string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake fd is a shell script")
+	}
+	bin := t.TempDir()
+	argsFile = filepath.Join(bin, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\n"
+	for _, line := range lines {
+		script += "echo '" + line + "'\n"
+	}
+	if err := os.WriteFile(filepath.Join(bin, "fd"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	return argsFile
+}
+
+func TestFdPruneAfterFilters(t *testing.T) {
+	root := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	for _, path := range []string{"a/x/f", "b/y/f"} {
+		touch(t, filepath.Join(root, path))
+	}
+	// a, a/x and b/y are old enough to match; b was just modified
+	for _, dir := range []string{"a/x", "a", "b/y"} {
+		if err := os.Chtimes(filepath.Join(root, dir), old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var lines []string
+	for _, dir := range []string{"a", "a/x", "b", "b/y"} {
+		lines = append(lines, filepath.Join(root, dir))
+	}
+	argsFile := fakeFd(t, lines)
+
+	s := NewSearcher(SearchOptions{
+		Root:   root,
+		Type:   "d",
+		Prune:  true,
+		Filter: Filter{OlderThan: time.Now().Add(-time.Hour)},
+	})
+	if !s.UsingFd() {
+		t.Fatal("fake fd not picked up")
+	}
+	var got []string
+	for r := range s.Search(context.Background()) {
+		rel, _ := filepath.Rel(root, r.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// b is rejected by the age filter, so the search must still reach b/y
+	if want := []string{"a", "b/y"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("found %q, want %q", got, want)
+	}
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(args), "--prune") {
+		t.Fatalf("fd ran with --prune under a metadata filter: %s", args)
+	}
+}
+
+// searchTree lays out a small tree for the built-in walker
+func searchTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, path := range []string{
+		"a.log",
+		"notes.txt",
+		"logs/b.log",
+		"logs/old/c.log",
+		"node_modules/d.log",
+	} {
+		touch(t, filepath.Join(root, path))
+	}
+	return root
+}
+
+// collect drains a search, returning matches relative to root in slash form
+func collect(t *testing.T, s *Searcher, root string) []string {
+	t.Helper()
+	var got []string
+	for r := range s.Search(context.Background()) {
+		rel, err := filepath.Rel(root, r.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.ToSlash(rel))
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	return got
+}
+
+func TestSearcherSearch(t *testing.T) {
+	root := searchTree(t)
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want []string
+	}{
+		{"pattern", SearchOptions{Pattern: "*.log"}, []string{"a.log", "logs/b.log", "logs/old/c.log"}},
+		{"ignore case", SearchOptions{Pattern: "*.LOG", IgnoreCase: true}, []string{"a.log", "logs/b.log", "logs/old/c.log"}},
+		{"all includes auto-excluded", SearchOptions{Pattern: "*.log", All: true},
+			[]string{"a.log", "logs/b.log", "logs/old/c.log", "node_modules/d.log"}},
+		{"directories only", SearchOptions{Type: "d"}, []string{"logs", "logs/old"}},
+		{"files only", SearchOptions{Pattern: "*o*", Type: "f"}, []string{"a.log", "logs/b.log", "logs/old/c.log", "notes.txt"}},
+		{"max depth", SearchOptions{Pattern: "*.log", MaxDepth: 2}, []string{"a.log", "logs/b.log"}},
+		{"min depth", SearchOptions{Pattern: "*.log", MinDepth: 2}, []string{"logs/b.log", "logs/old/c.log"}},
+		{"prune", SearchOptions{Pattern: "*o*", Type: "d", Prune: true}, []string{"logs"}},
+		{"filter", SearchOptions{Pattern: "*", Type: "f", Filter: Filter{Size: &SizeRange{Min: 1 << 20, Max: -1}}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Root = root
+			tt.opts.NoFd = true
+			s := NewSearcher(tt.opts)
+			if s.UsingFd() {
+				t.Fatal("NoFd searcher uses fd")
+			}
+			if got := collect(t, s, root); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("found %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearcherResults(t *testing.T) {
+	root := searchTree(t)
+	other := searchTree(t)
+	s := NewSearcher(SearchOptions{Pattern: "logs", Roots: []string{root, other}, NoFd: true})
+	if got := s.Roots(); !reflect.DeepEqual(got, []string{root, other}) {
+		t.Fatalf("Roots() = %q", got)
+	}
+
+	var results []Result
+	for r := range s.Search(context.Background()) {
+		results = append(results, r)
+	}
+	if len(results) != 2 {
+		t.Fatalf("found %d results, want 2", len(results))
+	}
+	for _, r := range results {
+		if !r.IsDir || filepath.Dir(r.Path) != r.Root || r.Category != CategorySafe {
+			t.Errorf("result %+v", r)
+		}
+	}
+}
+
+func TestSearcherLimit(t *testing.T) {
+	root := searchTree(t)
+	s := NewSearcher(SearchOptions{Root: root, Pattern: "*.log", Limit: 2, NoFd: true})
+	if got := collect(t, s, root); len(got) != 2 {
+		t.Fatalf("found %q with a limit of 2", got)
+	}
+	if !s.LimitReached() {
+		t.Fatal("LimitReached() = false")
+	}
+}
+
+func TestSearcherCancel(t *testing.T) {
+	root := searchTree(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewSearcher(SearchOptions{Root: root, NoFd: true})
+	for r := range s.Search(ctx) {
+		t.Fatalf("cancelled search found %s", r.Path)
+	}
+	if !errors.Is(s.Err(), context.Canceled) {
+		t.Fatalf("Err() = %v, want context.Canceled", s.Err())
+	}
+}
+
+func TestIsInside(t *testing.T) {
+	sep := string(filepath.Separator)
+	dir := filepath.Join(sep, "a", "b")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{dir, true},
+		{dir + sep, true},
+		{filepath.Join(dir, "c"), true},
+		{filepath.Join(dir, "c", "d"), true},
+		{filepath.Join(dir, "..b"), true},
+		{filepath.Join(sep, "a"), false},
+		{filepath.Join(sep, "a", "bc"), false},
+		{filepath.Join(sep, "a", "bc", "d"), false},
+		{filepath.Join(sep, "x"), false},
+	}
+	for _, tt := range tests {
+		if got := IsInside(tt.path, dir); got != tt.want {
+			t.Errorf("IsInside(%q, %q) = %v, want %v", tt.path, dir, got, tt.want)
+		}
+	}
+}
+
+func TestWalkStopsAtMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{"a.log", "logs/b.log", "logs/old/c.log", "logs/old/older/d.log"} {
+		touch(t, filepath.Join(root, path))
+	}
+
+	tests := []struct {
+		name     string
+		opts     SearchOptions
+		want     []string
+		examined int // entries the walk looked at; nothing below the limit is read
+	}{
+		{"files", SearchOptions{Pattern: "*", MaxDepth: 1}, []string{"a.log", "logs"}, 2},
+		{"two levels", SearchOptions{Pattern: "*", MaxDepth: 2}, []string{"a.log", "logs", "logs/b.log", "logs/old"}, 4},
+		{"empty dirs", SearchOptions{EmptyDirs: true, MaxDepth: 2}, nil, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Root = root
+			tt.opts.NoFd = true
+			s := NewSearcher(tt.opts)
+			if got := collect(t, s, root); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("found %q, want %q", got, tt.want)
+			}
+			if s.examined != tt.examined {
+				t.Fatalf("examined %d entries, want %d", s.examined, tt.examined)
+			}
+		})
+	}
+}
+
+func TestWalkMaxDepthUnreadable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("needs a directory the test cannot read")
+	}
+	root := t.TempDir()
+	locked := filepath.Join(root, "locked")
+	touch(t, filepath.Join(locked, "a.log"))
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	s := NewSearcher(SearchOptions{Root: root, MaxDepth: 1, NoFd: true})
+	if got := collect(t, s, root); !reflect.DeepEqual(got, []string{"locked"}) {
+		t.Fatalf("found %q", got)
+	}
+	if errs := s.Errors(); len(errs) != 0 {
+		t.Fatalf("directory at MaxDepth was read: %v", errs)
+	}
+}
+
+func TestSearchList(t *testing.T) {
+	root := searchTree(t)
+	tests := []struct {
+		name    string
+		list    string
+		nul     bool
+		opts    SearchOptions
+		want    []string
+		missing int
+	}{
+		{"newlines", "a.log\nlogs/b.log\n", false, SearchOptions{}, []string{"a.log", "logs/b.log"}, 0},
+		{"no trailing newline", "a.log\nnotes.txt", false, SearchOptions{}, []string{"a.log", "notes.txt"}, 0},
+		{"CRLF", "a.log\r\nnotes.txt\r\n", false, SearchOptions{}, []string{"a.log", "notes.txt"}, 0},
+		{"blank lines", "\n\na.log\n\n\nnotes.txt\n\n", false, SearchOptions{}, []string{"a.log", "notes.txt"}, 0},
+		{"NUL separated", "a.log\x00logs/old/c.log\x00", true, SearchOptions{}, []string{"a.log", "logs/old/c.log"}, 0},
+		{"NUL without trailing NUL", "a.log\x00notes.txt", true, SearchOptions{}, []string{"a.log", "notes.txt"}, 0},
+		{"NUL keeps newlines in names", "a.log\x00\x00we\nird\x00", true, SearchOptions{}, []string{"a.log"}, 1},
+		{"absolute paths", filepath.Join(root, "a.log") + "\n", false, SearchOptions{}, []string{"a.log"}, 0},
+		{"missing paths", "a.log\ngone.log\nlogs/gone\n", false, SearchOptions{}, []string{"a.log"}, 2},
+		{"with a pattern", "a.log\nnotes.txt\nlogs\nlogs/b.log\n", false, SearchOptions{Pattern: "*.log"}, []string{"a.log", "logs/b.log"}, 0},
+		{"with a type", "a.log\nlogs\n", false, SearchOptions{Type: "d"}, []string{"logs"}, 0},
+		{"auto-excluded", "node_modules/d.log\na.log\n", false, SearchOptions{}, []string{"a.log"}, 0},
+		{"empty list", "", false, SearchOptions{}, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Root = root
+			tt.opts.Paths = strings.NewReader(tt.list)
+			tt.opts.NulSeparated = tt.nul
+			s := NewSearcher(tt.opts)
+			if got := collect(t, s, root); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("found %q, want %q", got, tt.want)
+			}
+			if got := len(s.Errors()); got != tt.missing {
+				t.Fatalf("reported %d missing paths, want %d: %v", got, tt.missing, s.Errors())
+			}
+		})
+	}
+}
+
+func TestSearchListStrict(t *testing.T) {
+	root := searchTree(t)
+	s := NewSearcher(SearchOptions{Root: root, Paths: strings.NewReader("gone.log\na.log\n"), Strict: true})
+	for r := range s.Search(context.Background()) {
+		t.Fatalf("strict run went on to %s after a missing path", r.Path)
+	}
+	if s.Err() == nil {
+		t.Fatal("strict run ignored a missing path")
+	}
+}
+
+func TestDedupeRoots(t *testing.T) {
+	sep := string(filepath.Separator)
+	abs := func(parts ...string) string { return filepath.Join(append([]string{sep}, parts...)...) }
+
+	tests := []struct {
+		name  string
+		roots []string
+		want  []string
+	}{
+		{"single", []string{abs("a")}, []string{abs("a")}},
+		{"nested, outer first", []string{abs("a"), abs("a", "b")}, []string{abs("a")}},
+		{"nested, inner first", []string{abs("a", "b", "c"), abs("a")}, []string{abs("a")}},
+		{"duplicates", []string{abs("a"), abs("a"), abs("a") + sep}, []string{abs("a")}},
+		{"unclean duplicate", []string{abs("a", "b"), abs("a", "x", "..", "b")}, []string{abs("a", "b")}},
+		{"sibling prefixes", []string{abs("a", "b"), abs("a", "bc")}, []string{abs("a", "b"), abs("a", "bc")}},
+		{"sibling prefix of a nested root", []string{abs("a", "bc"), abs("a", "b"), abs("a", "b", "c")}, []string{abs("a", "bc"), abs("a", "b")}},
+		{"unrelated", []string{abs("x"), abs("y", "z")}, []string{abs("x"), abs("y", "z")}},
+		{"filesystem root covers everything", []string{abs("a"), sep}, []string{sep}},
+	}
+	for _, tt := range tests {
+		if got := DedupeRoots(tt.roots); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: DedupeRoots(%q) = %q, want %q", tt.name, tt.roots, got, tt.want)
+		}
+	}
+
+	// Relative and empty roots are resolved against the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DedupeRoots([]string{"", ".", "sub"}); !reflect.DeepEqual(got, []string{wd}) {
+		t.Errorf("DedupeRoots of relative roots = %q, want %q", got, wd)
+	}
+}
+
+func TestDedupeRootsSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	dir := t.TempDir()
+	real := filepath.Join(dir, "real")
+	link := filepath.Join(dir, "link")
+	touch(t, filepath.Join(real, "a"))
+	if err := os.Symlink(real, link); err != nil {
+		t.Fatal(err)
+	}
+
+	// A symlink to a root, or into it, searches the same entries
+	if got := DedupeRoots([]string{link, real}); !reflect.DeepEqual(got, []string{link}) {
+		t.Errorf("DedupeRoots(link, real) = %q", got)
+	}
+	if got := DedupeRoots([]string{real, filepath.Join(link, ".")}); !reflect.DeepEqual(got, []string{real}) {
+		t.Errorf("DedupeRoots(real, link) = %q", got)
+	}
+}
+
+func TestCommonRoot(t *testing.T) {
+	sep := string(filepath.Separator)
+	abs := func(parts ...string) string { return filepath.Join(append([]string{sep}, parts...)...) }
+
+	tests := []struct {
+		roots []string
+		want  string
+	}{
+		{[]string{abs("a", "b")}, abs("a", "b")},
+		{[]string{abs("a", "b", "c"), abs("a", "b", "d")}, abs("a", "b")},
+		{[]string{abs("a", "b"), abs("a", "bc")}, abs("a")},
+		{[]string{abs("a", "bc"), abs("a", "b", "c")}, abs("a")},
+		{[]string{abs("a", "b"), abs("a", "b", "c")}, abs("a", "b")},
+		{[]string{abs("a", "b"), abs("a", "b")}, abs("a", "b")},
+		{[]string{abs("x"), abs("y", "z")}, sep},
+		{[]string{abs("a", "b", "c"), abs("a", "b", "d"), abs("a", "e")}, abs("a")},
+	}
+	for _, tt := range tests {
+		if got := CommonRoot(tt.roots); got != tt.want {
+			t.Errorf("CommonRoot(%q) = %q, want %q", tt.roots, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package delf
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultShredPasses is the number of overwrite passes used when none is given
+const DefaultShredPasses = 3
+
+// ShredPath shreds a file, or every regular file inside a directory before removing it
+func ShredPath(path string, passes int) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return shredFile(path, info, passes)
+	}
+
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return shredFile(p, info, passes)
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// shredFile overwrites a file with random data (zeros on the final pass),
+// syncing after every pass, then scrubs its name and unlinks it.
+// Symlinks and special files are simply removed.
+func shredFile(path string, info os.FileInfo, passes int) error {
+	if info.Mode().IsRegular() && info.Size() > 0 {
+		if err := overwriteFile(path, info.Size(), passes); err != nil {
+			return err
+		}
+	}
+
+	scrubbed, err := scrubName(path)
+	if err != nil {
+		return err
+	}
+	return os.Remove(scrubbed)
+}
+
+// overwriteFile writes size bytes over the file once per pass
+func overwriteFile(path string, size int64, passes int) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for pass := 1; pass <= passes; pass++ {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		var src io.Reader = rand.Reader
+		if pass == passes {
+			src = zeroReader{}
+		}
+		if _, err := io.CopyN(f, src, size); err != nil {
+			return fmt.Errorf("overwrite pass %d: %w", pass, err)
+		}
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("sync pass %d: %w", pass, err)
+		}
+	}
+	return nil
+}
+
+// scrubName renames a file to random names of shrinking length so the
+// original name does not linger in the directory entry, returning the final path
+func scrubName(path string) (string, error) {
+	dir := filepath.Dir(path)
+	length := len(filepath.Base(path))
+	if length > 16 {
+		length = 16
+	}
+
+	for ; length > 0; length /= 2 {
+		buf := make([]byte, (length+1)/2)
+		if _, err := rand.Read(buf); err != nil {
+			return path, err
+		}
+		next := filepath.Join(dir, hex.EncodeToString(buf)[:length])
+		if _, err := os.Lstat(next); err == nil {
+			continue // never clobber an existing file
+		}
+		if err := os.Rename(path, next); err != nil {
+			return path, err
+		}
+		syncDir(dir)
+		path = next
+	}
+	return path, nil
+}
+
+// syncDir flushes directory metadata where the platform allows it
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// zeroReader is an endless source of zero bytes
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// ShredWarnings returns one warning per filesystem where overwriting in place
+// is not guaranteed to destroy the old contents
+func ShredWarnings(results []Result) []string {
+	var warnings []string
+	seen := make(map[string]bool)
+	checked := make(map[string]bool)
+	for _, r := range results {
+		dir := filepath.Dir(r.Path)
+		if checked[dir] {
+			continue
+		}
+		checked[dir] = true
+
+		warning := shredWarning(dir)
+		if warning != "" && !seen[warning] {
+			seen[warning] = true
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
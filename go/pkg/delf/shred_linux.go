@@ -0,0 +1,52 @@
+package delf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Filesystems that never overwrite blocks in place
+var copyOnWriteFilesystems = map[uint32]string{
+	0x9123683E: "btrfs",
+	0x2FC12FC1: "zfs",
+	0xF2F52010: "f2fs",
+	0x3434:     "nilfs2",
+}
+
+// shredWarning explains why shredding may not reach the disk blocks holding path
+func shredWarning(path string) string {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err == nil {
+		if name, ok := copyOnWriteFilesystems[uint32(fs.Type)]; ok {
+			return fmt.Sprintf("%s is copy-on-write; old contents may survive shredding", name)
+		}
+	}
+
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return ""
+	}
+	rdev := uint64(st.Dev)
+	major := (rdev>>8)&0xfff | (rdev>>32)&^0xfff
+	minor := rdev&0xff | (rdev>>12)&^0xff
+
+	// Partitions keep queue settings on their parent device
+	dev, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", major, minor))
+	if err != nil {
+		return ""
+	}
+	for _, dir := range []string{dev, filepath.Dir(dev)} {
+		rotational, err := os.ReadFile(filepath.Join(dir, "queue", "rotational"))
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(rotational)) == "0" {
+			return fmt.Sprintf("%s is a solid-state device; wear levelling may keep old contents", filepath.Base(dev))
+		}
+		break
+	}
+	return ""
+}
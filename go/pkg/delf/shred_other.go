@@ -0,0 +1,8 @@
+//go:build !linux
+
+package delf
+
+// shredWarning cannot inspect the filesystem or device on this platform
+func shredWarning(path string) string {
+	return ""
+}
@@ -0,0 +1,35 @@
+package delf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// moveToTrash moves a path to ~/.Trash, adding a timestamp on name clashes
+func moveToTrash(path string) error {
+	trashDir, err := homeTrashDir()
+	if err != nil {
+		return err
+	}
+
+	base := filepath.Base(path)
+	target := filepath.Join(trashDir, base)
+	if _, err := os.Lstat(target); err == nil {
+		ext := filepath.Ext(base)
+		target = filepath.Join(trashDir, fmt.Sprintf("%s %s%s",
+			strings.TrimSuffix(base, ext), time.Now().Format("15.04.05.000"), ext))
+	}
+	return os.Rename(path, target)
+}
+
+// homeTrashDir returns ~/.Trash
+func homeTrashDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".Trash"), nil
+}
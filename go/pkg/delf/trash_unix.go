@@ -0,0 +1,108 @@
+//go:build !windows && !darwin
+
+package delf
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// moveToTrash moves a path to the freedesktop.org trash: the home trash
+// when it is on the same filesystem, otherwise $topdir/.Trash-$uid
+func moveToTrash(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	trashDir, err := homeTrashDir()
+	if err != nil {
+		return err
+	}
+	err = trashInto(trashDir, abs)
+	if !isCrossDevice(err) {
+		return err
+	}
+
+	top, err := mountTop(abs)
+	if err != nil {
+		return err
+	}
+	return trashInto(filepath.Join(top, ".Trash-"+strconv.Itoa(os.Getuid())), abs)
+}
+
+// homeTrashDir returns $XDG_DATA_HOME/Trash
+func homeTrashDir() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// trashInto writes the .trashinfo record and renames path into trashDir/files
+func trashInto(trashDir, path string) error {
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return err
+	}
+
+	// Reserve a unique name by creating its info file exclusively
+	base := filepath.Base(path)
+	for i := 0; ; i++ {
+		name := base
+		if i > 0 {
+			name = fmt.Sprintf("%s.%d", base, i)
+		}
+
+		infoPath := filepath.Join(infoDir, name+".trashinfo")
+		info, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		escaped := (&url.URL{Path: path}).EscapedPath()
+		fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n", escaped, time.Now().Format("2006-01-02T15:04:05"))
+		info.Close()
+
+		if err := os.Rename(path, filepath.Join(filesDir, name)); err != nil {
+			os.Remove(infoPath)
+			return err
+		}
+		return nil
+	}
+}
+
+// mountTop returns the top directory of the filesystem holding path
+func mountTop(path string) (string, error) {
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(path)
+	for {
+		parent := filepath.Dir(dir)
+		var pst syscall.Stat_t
+		if parent == dir || syscall.Stat(parent, &pst) != nil || pst.Dev != st.Dev {
+			return dir, nil
+		}
+		dir = parent
+	}
+}
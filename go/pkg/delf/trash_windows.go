@@ -0,0 +1,31 @@
+package delf
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// moveToTrash sends a path to the Recycle Bin through the .NET
+// Microsoft.VisualBasic file APIs, which PowerShell exposes everywhere
+func moveToTrash(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	method := "DeleteFile"
+	if info.IsDir() {
+		method = "DeleteDirectory"
+	}
+	quoted := "'" + strings.ReplaceAll(path, "'", "''") + "'"
+	script := fmt.Sprintf("Add-Type -AssemblyName Microsoft.VisualBasic; "+
+		"[Microsoft.VisualBasic.FileIO.FileSystem]::%s(%s, 'OnlyErrorDialogs', 'SendToRecycleBin')", method, quoted)
+
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("recycle bin: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// runRestoreCommand implements `delf restore MANIFEST`, replaying a move in reverse
+func runRestoreCommand(args []string) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	dryRun := fs.Bool("n", false, "Preview only, don't move anything back")
+	fs.BoolVar(dryRun, "dry-run", false, "Preview only, don't move anything back")
+	fs.Usage = showRestoreHelp
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		showRestoreHelp()
+		os.Exit(1)
+	}
+
+	entries, err := delf.ReadManifest(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold(fmt.Sprintf("Restoring %d items...", len(entries))))
+	fmt.Fprintln(console)
+
+	verb := "Restored"
+	if *dryRun {
+		verb = "Would restore"
+	}
+
+	restored, failed := 0, 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+
+		err := delf.RestoreEntry(entry, *dryRun)
+		if err != nil {
+			failed++
+			fmt.Fprintf(console, "%s Failed: %s %s\n", colors.Red("X"), entry.From, colors.Yellow(fmt.Sprintf("(%s)", err)))
+			continue
+		}
+		restored++
+		fmt.Fprintf(console, "%s %s: %s\n", colors.Green("OK"), verb, entry.From)
+	}
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintf(console, "%s %d items\n", colors.Green(colors.Bold("OK "+verb+":")), restored)
+	if failed > 0 {
+		fmt.Fprintf(console, "%s %d items\n", colors.BoldRed("X Failed:"), failed)
+		os.Exit(1)
+	}
+}
+
+// showRestoreHelp displays usage for `delf restore`
+func showRestoreHelp() {
+	fmt.Fprintln(console, colors.Bold("USAGE:"))
+	fmt.Fprintln(console, "    delf restore [OPTIONS] MANIFEST")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, "    Moves everything recorded in a --move-to manifest back to where it was.")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("OPTIONS:"))
+	fmt.Fprintf(console, "    %s    Preview only, don't move anything back\n", colors.Cyan("-n, --dry-run"))
+}
@@ -0,0 +1,29 @@
+package main
+
+import "strings"
+
+// rootsFlag implements --root, which may be given more than once
+type rootsFlag struct {
+	roots *[]string
+}
+
+func (f rootsFlag) String() string {
+	if f.roots == nil {
+		return ""
+	}
+	return strings.Join(*f.roots, ",")
+}
+
+func (f rootsFlag) Set(value string) error {
+	*f.roots = append(*f.roots, value)
+	return nil
+}
+
+// searchRoots returns the directories to search when several were given, or
+// nil when the search path is the only one
+func searchRoots() []string {
+	if len(opts.Roots) < 2 {
+		return nil
+	}
+	return opts.Roots
+}
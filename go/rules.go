@@ -0,0 +1,333 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// cleanupRule is one [[rule]] of a rules file, turned into the options a
+// command line with the same flags would set
+type cleanupRule struct {
+	Name      string
+	Line      int
+	Enabled   bool
+	opts      Options
+	filter    delf.Filter
+	retention delf.Retention
+}
+
+// ruleKey applies one rules-file key to the options
+type ruleKey func(o *Options, value interface{}) error
+
+// ruleKeys maps rules-file keys onto options; they are named after the
+// long flags, with underscores instead of dashes
+var ruleKeys = map[string]ruleKey{
+	"path":        stringKey(func(o *Options) *string { return &o.Path }),
+	"paths":       stringsKey(func(o *Options) *[]string { return &o.Roots }),
+	"pattern":     stringKey(func(o *Options) *string { return &o.Pattern }),
+	"type":        stringKey(func(o *Options) *string { return &o.Type }),
+	"ignore_case": boolKey(func(o *Options) *bool { return &o.IgnoreCase }),
+	"all":         boolKey(func(o *Options) *bool { return &o.All }),
+
+	"older_than":   stringKey(func(o *Options) *string { return &o.OlderThan }),
+	"newer_than":   stringKey(func(o *Options) *string { return &o.NewerThan }),
+	"time_field":   stringKey(func(o *Options) *string { return &o.TimeField }),
+	"larger_than":  stringKey(func(o *Options) *string { return &o.LargerThan }),
+	"smaller_than": stringKey(func(o *Options) *string { return &o.SmallerThan }),
+	"size":         stringKey(func(o *Options) *string { return &o.Size }),
+	"dir_size":     boolKey(func(o *Options) *bool { return &o.DirSize }),
+	"user":         stringKey(func(o *Options) *string { return &o.User }),
+	"group":        stringKey(func(o *Options) *string { return &o.Group }),
+	"uid":          intKey(func(o *Options) *int { return &o.UID }),
+	"gid":          intKey(func(o *Options) *int { return &o.GID }),
+	"nouser":       boolKey(func(o *Options) *bool { return &o.NoUser }),
+	"perm":         stringKey(func(o *Options) *string { return &o.Perm }),
+	"mime":         stringKey(func(o *Options) *string { return &o.Mime }),
+	"kind":         stringKey(func(o *Options) *string { return &o.Kind }),
+	"executable":   boolKey(func(o *Options) *bool { return &o.Executable }),
+	"contains":     stringKey(func(o *Options) *string { return &o.Contains }),
+
+	"max_depth":  intKey(func(o *Options) *int { return &o.MaxDepth }),
+	"min_depth":  intKey(func(o *Options) *int { return &o.MinDepth }),
+	"prune":      boolKey(func(o *Options) *bool { return &o.Prune }),
+	"limit":      intKey(func(o *Options) *int { return &o.Limit }),
+	"strict":     boolKey(func(o *Options) *bool { return &o.Strict }),
+	"empty_dirs": boolKey(func(o *Options) *bool { return &o.EmptyDirs }),
+
+	"keep_newest":  intKey(func(o *Options) *int { return &o.KeepNewest }),
+	"keep_oldest":  intKey(func(o *Options) *int { return &o.KeepOldest }),
+	"keep_daily":   intKey(func(o *Options) *int { return &o.KeepDaily }),
+	"keep_weekly":  intKey(func(o *Options) *int { return &o.KeepWeekly }),
+	"keep_monthly": intKey(func(o *Options) *int { return &o.KeepMonthly }),
+	"group_by":     stringKey(func(o *Options) *string { return &o.GroupBy }),
+
+	"git_safe":  boolKey(func(o *Options) *bool { return &o.GitSafe }),
+	"skip_busy": boolKey(func(o *Options) *bool { return &o.SkipBusy }),
+	"shred":     intKey(func(o *Options) *int { return &o.Shred }),
+	"trash":     boolKey(func(o *Options) *bool { return &o.Trash }),
+	"archive":   stringKey(func(o *Options) *string { return &o.Archive }),
+	"move_to":   stringKey(func(o *Options) *string { return &o.MoveTo }),
+	"dry_run":   boolKey(func(o *Options) *bool { return &o.DryRun }),
+	"show_size": boolKey(func(o *Options) *bool { return &o.ShowSize }),
+
+	"no_audit":   boolKey(func(o *Options) *bool { return &o.NoAudit }),
+	"audit_log":  stringKey(func(o *Options) *string { return &o.AuditLog }),
+	"audit_hash": boolKey(func(o *Options) *bool { return &o.AuditHash }),
+	"syslog":     boolKey(func(o *Options) *bool { return &o.SysLog }),
+}
+
+func stringKey(field func(o *Options) *string) ruleKey {
+	return func(o *Options, value interface{}) error {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("must be a string")
+		}
+		*field(o) = s
+		return nil
+	}
+}
+
+func stringsKey(field func(o *Options) *[]string) ruleKey {
+	return func(o *Options, value interface{}) error {
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("must be an array of strings")
+		}
+		var list []string
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("must be an array of strings")
+			}
+			list = append(list, s)
+		}
+		*field(o) = list
+		return nil
+	}
+}
+
+func intKey(field func(o *Options) *int) ruleKey {
+	return func(o *Options, value interface{}) error {
+		n, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("must be an integer")
+		}
+		*field(o) = int(n)
+		return nil
+	}
+}
+
+func boolKey(field func(o *Options) *bool) ruleKey {
+	return func(o *Options, value interface{}) error {
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("must be true or false")
+		}
+		*field(o) = b
+		return nil
+	}
+}
+
+// defaultOptions returns the options a bare command line starts from
+func defaultOptions() Options {
+	return Options{TimeField: "mtime", UID: -1, GID: -1, MaxDisplay: 100}
+}
+
+// loadRules reads a rules file. Top-level keys are defaults for every rule.
+func loadRules(path string) ([]*cleanupRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	top, tables, err := parseTOML(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("%s: no [[rule]] entries", path)
+	}
+
+	defaults := defaultOptions()
+	for _, key := range top.keys {
+		if key == "name" || key == "enabled" {
+			return nil, fmt.Errorf("%s: line %d: %s can only be set inside a [[rule]]", path, top.lines[key], key)
+		}
+		if err := applyRuleKey(&defaults, top, key); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	var rules []*cleanupRule
+	names := make(map[string]bool)
+	now := time.Now()
+	dests := make(map[string]string) // archive and move_to destination -> rule name
+	for i, table := range tables {
+		rule := &cleanupRule{Name: fmt.Sprintf("rule %d", i+1), Line: table.line, Enabled: true, opts: defaults}
+		for _, key := range table.keys {
+			var err error
+			switch key {
+			case "name":
+				name, ok := table.values[key].(string)
+				if !ok || name == "" {
+					err = fmt.Errorf("line %d: name must be a non-empty string", table.lines[key])
+				}
+				rule.Name = name
+			case "enabled":
+				enabled, ok := table.values[key].(bool)
+				if !ok {
+					err = fmt.Errorf("line %d: enabled must be true or false", table.lines[key])
+				}
+				rule.Enabled = enabled
+			default:
+				err = applyRuleKey(&rule.opts, table, key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("%s: line %d: another rule is already named %q", path, rule.Line, rule.Name)
+		}
+		names[rule.Name] = true
+		if err := rule.prepare(now); err != nil {
+			return nil, fmt.Errorf("%s: rule %q (line %d): %w", path, rule.Name, rule.Line, err)
+		}
+
+		// Two rules writing one archive or move manifest would clobber each other
+		for _, dest := range []string{rule.opts.Archive, rule.opts.MoveTo} {
+			if dest == "" {
+				continue
+			}
+			dest = filepath.Clean(dest)
+			if other, ok := dests[dest]; ok {
+				return nil, fmt.Errorf("%s: rule %q (line %d): %s is already the destination of rule %q", path, rule.Name, rule.Line, dest, other)
+			}
+			dests[dest] = rule.Name
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// applyRuleKey sets one key of table on o
+func applyRuleKey(o *Options, table *tomlTable, key string) error {
+	apply, ok := ruleKeys[key]
+	if !ok {
+		return fmt.Errorf("line %d: unknown key %s (known keys: %s)", table.lines[key], key, strings.Join(ruleKeyNames(), ", "))
+	}
+	if err := apply(o, table.values[key]); err != nil {
+		return fmt.Errorf("line %d: %s %w", table.lines[key], key, err)
+	}
+	return nil
+}
+
+// ruleKeyNames returns the known keys, sorted
+func ruleKeyNames() []string {
+	names := make([]string, 0, len(ruleKeys))
+	for name := range ruleKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// prepare expands the rule's paths and checks its options the way the command
+// line does, so a broken rule is reported before any rule runs
+func (r *cleanupRule) prepare(now time.Time) error {
+	o := &r.opts
+
+	if o.Path != "" && len(o.Roots) > 0 {
+		return fmt.Errorf("set path or paths, not both")
+	}
+	if o.Path != "" {
+		o.Roots = []string{o.Path}
+	}
+	if len(o.Roots) == 0 {
+		return fmt.Errorf("path is required (rules never search the current directory)")
+	}
+	roots := make([]string, len(o.Roots))
+	for i, root := range o.Roots {
+		roots[i] = expandHome(root)
+	}
+	o.Roots = roots
+	o.Path = o.Roots[0]
+	if o.Archive != "" && !strings.Contains(o.Archive, dateField) {
+		return fmt.Errorf("archive must contain %s so each run writes a new file, e.g. \"/backups/logs-%s.tar.gz\"", dateField, dateField)
+	}
+	o.Archive = expandDate(expandHome(o.Archive), now)
+	o.MoveTo = expandDate(expandHome(o.MoveTo), now)
+	o.AuditLog = expandHome(o.AuditLog)
+
+	if o.Pattern == "" && !o.EmptyDirs {
+		return fmt.Errorf("pattern is required (or empty_dirs = true)")
+	}
+	if o.Type != "" && o.Type != "f" && o.Type != "d" {
+		return fmt.Errorf("type must be \"f\" (file) or \"d\" (directory)")
+	}
+	if o.MaxDepth > 0 && o.MinDepth > o.MaxDepth {
+		return fmt.Errorf("min_depth cannot be greater than max_depth")
+	}
+	if o.Shred < 0 {
+		return fmt.Errorf("shred cannot be negative")
+	}
+
+	modes := delf.DeleteOptions{Trash: o.Trash, Archive: o.Archive, MoveTo: o.MoveTo, Shred: o.Shred}
+	if delf.CountDeleteModes(modes) > 1 {
+		return fmt.Errorf("trash, archive, move_to and shred cannot be combined")
+	}
+	if o.Archive != "" {
+		if err := delf.ValidateArchivePath(o.Archive); err != nil {
+			return err
+		}
+		if err := delf.CheckDestination("archive", o.Archive, o.Roots); err != nil {
+			return err
+		}
+	}
+	if o.MoveTo != "" {
+		if err := delf.CheckDestination("move_to", o.MoveTo, o.Roots); err != nil {
+			return err
+		}
+	}
+
+	// The filter parser reads the global options
+	saved := opts
+	opts = *o
+	defer func() { opts = saved }()
+
+	filter, err := newSearchFilter(now)
+	if err != nil {
+		return err
+	}
+	r.filter = filter
+	if r.retention, err = newRetention(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// dateField is replaced by the run's start time in archive and move_to paths
+const dateField = "{date}"
+
+// expandDate replaces every {date} in path with now, e.g. 20250101-120000
+func expandDate(path string, now time.Time) string {
+	return strings.ReplaceAll(path, dateField, now.Format("20060102-150405"))
+}
+
+// expandHome replaces a leading ~ with the home directory, since rules files
+// are not expanded by a shell
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
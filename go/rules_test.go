@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeRules writes a rules file into a temporary directory
+func writeRules(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.toml")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadRulesArchiveDate(t *testing.T) {
+	path := writeRules(t, `
+[[rule]]
+path = "`+filepath.ToSlash(t.TempDir())+`"
+pattern = "*.log"
+archive = "`+filepath.ToSlash(t.TempDir())+`/logs-{date}.tar.gz"
+`)
+	before := time.Now().Truncate(time.Second)
+	rules, err := loadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Base(rules[0].opts.Archive)
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, "logs-"), ".tar.gz")
+	when, err := time.ParseInLocation("20060102-150405", stamp, time.Local)
+	if err != nil {
+		t.Fatalf("archive %s has no run time: %v", name, err)
+	}
+	if when.Before(before) || when.After(time.Now()) {
+		t.Fatalf("archive %s is not stamped with the run time", name)
+	}
+}
+
+func TestLoadRulesDestinations(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"fixed archive",
+			`[[rule]]
+path = "` + dir + `"
+pattern = "*.log"
+archive = "` + dir + `/logs.tar.gz"
+`,
+			"archive must contain {date}",
+		},
+		{
+			"archive inside the search path",
+			`[[rule]]
+path = "` + dir + `"
+pattern = "*.log"
+archive = "` + dir + `/logs-{date}.tar.gz"
+`,
+			"is inside the search path",
+		},
+		{
+			"move_to inside the search path",
+			`[[rule]]
+path = "` + dir + `"
+pattern = "*.log"
+move_to = "` + dir + `/quarantine"
+`,
+			"is inside the search path",
+		},
+		{
+			"shared archive",
+			`pattern = "*.log"
+archive = "` + dir + `/logs-{date}.tar.gz"
+[[rule]]
+name = "a"
+path = "` + dir + `/a"
+[[rule]]
+name = "b"
+path = "` + dir + `/b"
+`,
+			`is already the destination of rule "a"`,
+		},
+		{
+			"shared move_to",
+			`[[rule]]
+name = "a"
+path = "` + dir + `/a"
+pattern = "*.log"
+move_to = "` + dir + `/old"
+[[rule]]
+name = "b"
+path = "` + dir + `/b"
+pattern = "*.tmp"
+move_to = "` + dir + `/old/"
+`,
+			`is already the destination of rule "a"`,
+		},
+		{
+			"archive into move_to",
+			`[[rule]]
+name = "a"
+path = "` + dir + `/a"
+pattern = "*.log"
+move_to = "` + dir + `/out-{date}.tar"
+[[rule]]
+name = "b"
+path = "` + dir + `/b"
+pattern = "*.tmp"
+archive = "` + dir + `/out-{date}.tar"
+`,
+			`is already the destination of rule "a"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadRules(writeRules(t, tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("loadRules error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
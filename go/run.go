@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// ruleReport is the outcome of one rule in a `delf run`
+type ruleReport struct {
+	name      string
+	disabled  bool
+	dryRun    bool
+	verb      string
+	matched   int // matches after nested ones were folded into their directory
+	kept      int // held back by the retention rules
+	protected int // critical system paths and, with git_safe, tracked files
+	deleted   int
+	failed    int
+	skipped   int
+	left      int // not reached before an interrupt
+	err       error
+}
+
+// ok reports whether the rule ran without errors or failed items
+func (r ruleReport) ok() bool {
+	return r.err == nil && r.failed == 0 && r.left == 0
+}
+
+// runRunCommand implements `delf run RULES`, applying every cleanup rule in a
+// rules file without prompts. The exit status is 0 when every rule ran
+// cleanly, 1 when a rule could not run or an item failed, and 130 when interrupted.
+func runRunCommand(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	dryRun := fs.Bool("n", false, "Preview only, don't delete anything")
+	fs.BoolVar(dryRun, "dry-run", false, "Preview only, don't delete anything")
+	only := fs.String("rule", "", "Only run the rules with these names (comma-separated)")
+	check := fs.Bool("check", false, "Check the rules file and exit")
+	quiet := fs.Bool("q", false, "Only print rule headers, summaries and failures")
+	fs.BoolVar(quiet, "quiet", false, "Only print rule headers, summaries and failures")
+	noAudit := fs.Bool("no-audit", false, "Don't record deletions in the audit log")
+	auditLog := fs.String("audit-log", "", "Audit log file (default: $XDG_STATE_HOME/delf/audit.log)")
+	auditHash := fs.Bool("audit-hash", false, "Record the SHA-256 of each deleted file")
+	sysLog := fs.Bool("syslog", false, "Also forward audit records to syslog/journald")
+	fs.Usage = showRunHelp
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		showRunHelp()
+		os.Exit(1)
+	}
+
+	rules, err := loadRules(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+	if *only != "" {
+		if rules, err = selectRules(rules, *only); err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			os.Exit(1)
+		}
+	}
+
+	if *check {
+		fmt.Fprintf(console, "%s %s: %d rules\n", colors.Green("OK"), fs.Arg(0), len(rules))
+		for _, rule := range rules {
+			state := ""
+			if !rule.Enabled {
+				state = colors.Dim(" (disabled)")
+			}
+			fmt.Fprintf(console, "  %s: %s%s\n", colors.Cyan(rule.Name), strings.Join(rule.opts.Roots, ", "), state)
+		}
+		return
+	}
+
+	ctx, stop := withSignals()
+	var reports []ruleReport
+	for _, rule := range rules {
+		if ctx.Err() != nil {
+			break
+		}
+		if !rule.Enabled {
+			reports = append(reports, ruleReport{name: rule.Name, disabled: true})
+			continue
+		}
+
+		// Each rule runs with its own options, as if given on the command line
+		opts = rule.opts
+		opts.Force = true
+		opts.DryRun = opts.DryRun || *dryRun
+		opts.Quiet = *quiet
+		opts.NoAudit = opts.NoAudit || *noAudit
+		opts.AuditHash = opts.AuditHash || *auditHash
+		opts.SysLog = opts.SysLog || *sysLog
+		if *auditLog != "" {
+			opts.AuditLog = *auditLog
+		}
+		filter, retention = rule.filter, rule.retention
+		sink = newSink()
+		if t, ok := sink.(*terminalSink); ok {
+			t.streaming = true
+		}
+
+		reports = append(reports, runRule(ctx, rule.Name))
+	}
+	interrupted := ctx.Err() != nil
+	stop()
+
+	showRunSummary(reports)
+	if interrupted {
+		fmt.Fprintln(console)
+		fmt.Fprintf(console, "%s rules that had not started were not run\n", colors.Yellow(colors.Bold("Interrupted:")))
+		os.Exit(exitInterrupted)
+	}
+	for _, report := range reports {
+		if !report.ok() {
+			os.Exit(1)
+		}
+	}
+}
+
+// selectRules keeps the rules named in a comma-separated list
+func selectRules(rules []*cleanupRule, names string) ([]*cleanupRule, error) {
+	wanted := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			wanted[name] = true
+		}
+	}
+
+	var selected []*cleanupRule
+	for _, rule := range rules {
+		if wanted[rule.Name] {
+			selected = append(selected, rule)
+			delete(wanted, rule.Name)
+		}
+	}
+	for name := range wanted {
+		return nil, fmt.Errorf("no rule named %q", name)
+	}
+	return selected, nil
+}
+
+// runRule searches and deletes for the rule whose options are in opts. Critical
+// system paths are never deleted by rules, whoever runs them.
+func runRule(ctx context.Context, name string) ruleReport {
+	report := ruleReport{name: name, dryRun: opts.DryRun}
+
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintf(console, "%s %s\n", colors.Bold("Rule:"), colors.Cyan(name))
+
+	searcher := delf.NewSearcher(searchOptions(opts.Pattern, opts.Path))
+	pattern := opts.Pattern
+	if pattern == "" {
+		pattern = "*"
+	}
+	showSearchHeader(searcher, pattern)
+
+	var results []delf.Result
+	for result := range searcher.Search(ctx) {
+		results = append(results, result)
+	}
+	if ctx.Err() != nil {
+		report.err = fmt.Errorf("interrupted during the search, nothing deleted")
+		return report
+	}
+	if !opts.Strict {
+		showUnreadable(searcher.Errors())
+	}
+	if err := searcher.Err(); err != nil {
+		report.err = err
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		return report
+	}
+
+	// Same safety steps as the interactive flow, without the prompts
+	results = delf.CollapseNested(results)
+	report.matched = len(results)
+	critical, _, _ := countByCategory(results)
+	if critical > 0 {
+		results = filterOutCritical(results)
+		report.protected += critical
+		fmt.Fprintf(console, "%s %d critical system paths left in place\n", colors.Yellow("! Protected:"), critical)
+	}
+	if opts.GitSafe {
+		annotated, err := delf.AnnotateGitStatus(results)
+		if err != nil {
+			report.err = err
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			return report
+		}
+		var protected []delf.Result
+		results, protected = delf.FilterGitProtected(annotated)
+		report.protected += len(protected)
+		showGitProtectedFiles(protected)
+	}
+
+	if retention.Active() {
+		var kept []delf.Result
+		results, kept = retention.Apply(results)
+		report.kept = len(kept)
+		fmt.Fprintf(console, "%s %d of %d matches (%s)\n", colors.Green("Kept:"), len(kept), report.matched, retention)
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(console, colors.Green("Nothing to delete"))
+		return report
+	}
+
+	deleter, err := newDeleter()
+	if err != nil {
+		report.err = err
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		return report
+	}
+	report.verb = deleter.Verb()
+	if opts.Shred > 0 {
+		showShredWarnings(delf.ShredWarnings(results), opts.Shred)
+	}
+
+	var audit *auditLog
+	if !opts.NoAudit && !opts.DryRun {
+		audit, err = openAuditLog(auditPath(), opts.AuditHash, opts.SysLog)
+		if err != nil {
+			fmt.Fprintf(console, "%s audit log unavailable: %v\n", colors.Yellow("Warning:"), err)
+		}
+	}
+
+	// Sizes are measured while the matches still exist
+	var size int64
+	if opts.ShowSize {
+		size = delf.TotalSize(results)
+	}
+
+	results, _ = delf.AnnotateOpenFiles(results)
+	var left []delf.Result
+	report.deleted, report.failed, report.skipped, left = performDeletion(ctx, results, deleter, audit)
+	report.left = len(left)
+	audit.close(report.deleted, report.failed, report.skipped, len(left), len(left) > 0)
+
+	showDeletionProgress(deleter.Verb(), report.deleted, report.failed, report.skipped)
+	if opts.ShowSize {
+		fmt.Fprintf(console, "%s %s\n", colors.Bold("Total size:"), colors.Yellow(formatSize(size)))
+	}
+	return report
+}
+
+// showRunSummary lists the outcome of every rule
+func showRunSummary(reports []ruleReport) {
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"))
+	fmt.Fprintln(console, colors.Bold("Rules:"))
+	for _, r := range reports {
+		switch {
+		case r.disabled:
+			fmt.Fprintf(console, "  %s %s %s\n", colors.Dim("- "), r.name, colors.Dim("(disabled)"))
+		case r.err != nil:
+			fmt.Fprintf(console, "  %s %s %s\n", colors.Red("X "), r.name, colors.Yellow(fmt.Sprintf("(%v)", r.err)))
+		default:
+			mark := colors.Green("OK")
+			if !r.ok() {
+				mark = colors.Red("X ")
+			}
+			verb := r.verb
+			if verb == "" {
+				verb = "Deleted"
+			}
+			parts := []string{fmt.Sprintf("%d matched", r.matched), fmt.Sprintf("%d %s", r.deleted, strings.ToLower(verb))}
+			if r.kept > 0 {
+				parts = append(parts, fmt.Sprintf("%d kept", r.kept))
+			}
+			if r.protected > 0 {
+				parts = append(parts, fmt.Sprintf("%d protected", r.protected))
+			}
+			if r.skipped > 0 {
+				parts = append(parts, fmt.Sprintf("%d skipped", r.skipped))
+			}
+			if r.failed > 0 {
+				parts = append(parts, fmt.Sprintf("%d failed", r.failed))
+			}
+			if r.left > 0 {
+				parts = append(parts, fmt.Sprintf("%d not reached", r.left))
+			}
+			if r.dryRun {
+				parts = append(parts, "dry run")
+			}
+			fmt.Fprintf(console, "  %s %s: %s\n", mark, r.name, strings.Join(parts, ", "))
+		}
+	}
+}
+
+// showRunHelp displays usage for `delf run`
+func showRunHelp() {
+	fmt.Fprintln(console, colors.Bold("USAGE:"))
+	fmt.Fprintln(console, "    delf run [OPTIONS] RULES")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, "    Applies every [[rule]] in the RULES file without prompting, for cron jobs")
+	fmt.Fprintln(console, "    and systemd timers. Rule keys are named after the long options, with")
+	fmt.Fprintln(console, "    underscores (older_than = \"14d\"); top-level keys apply to every rule.")
+	fmt.Fprintln(console, "    Critical system paths are never deleted by rules.")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, "    Exit status: 0 when every rule ran cleanly (matching nothing is fine),")
+	fmt.Fprintln(console, "    1 when a rule could not run or an item failed, 130 when interrupted.")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("OPTIONS:"))
+	fmt.Fprintf(console, "    %s    Preview every rule, don't delete anything\n", colors.Cyan("-n, --dry-run"))
+	fmt.Fprintf(console, "    %s     Only run the named rules (comma-separated)\n", colors.Cyan("--rule NAMES"))
+	fmt.Fprintf(console, "    %s          Check the rules file and exit\n", colors.Cyan("--check"))
+	fmt.Fprintf(console, "    %s      Only print rule headers, summaries and failures\n", colors.Cyan("-q, --quiet"))
+	fmt.Fprintf(console, "    %s       Don't record deletions in the audit log\n", colors.Cyan("--no-audit"))
+	fmt.Fprintf(console, "    %s Audit log file (default: $XDG_STATE_HOME/delf/audit.log)\n", colors.Cyan("--audit-log FILE"))
+	fmt.Fprintf(console, "    %s     Record the SHA-256 of each deleted file\n", colors.Cyan("--audit-hash"))
+	fmt.Fprintf(console, "    %s         Also forward audit records to syslog/journald\n", colors.Cyan("--syslog"))
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, colors.Bold("EXAMPLE RULES FILE:"))
+	fmt.Fprintln(console, "    [[rule]]")
+	fmt.Fprintln(console, "    name = \"app logs\"")
+	fmt.Fprintln(console, "    path = \"/var/app/logs\"")
+	fmt.Fprintln(console, "    pattern = \"*.log\"")
+	fmt.Fprintln(console, "    older_than = \"14d\"")
+	fmt.Fprintln(console, "    keep_newest = 5")
+	fmt.Fprintln(console)
+	fmt.Fprintln(console, "    [[rule]]")
+	fmt.Fprintln(console, "    name = \"old disk images\"")
+	fmt.Fprintln(console, "    path = \"~/Downloads\"")
+	fmt.Fprintln(console, "    pattern = \"*.dmg\"")
+	fmt.Fprintln(console, "    older_than = \"30d\"")
+	fmt.Fprintln(console, "    trash = true")
+}
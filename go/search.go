@@ -1,326 +1,108 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
-	"time"
-)
-
-// SearchResult holds a file path and its category
-type SearchResult struct {
-	Path     string
-	Category FileCategory
-	IsDir    bool
-}
-
-// hasFd checks if fd is available in PATH
-func hasFd() bool {
-	_, err := exec.LookPath("fd")
-	return err == nil
-}
-
-// searchWithFd uses fd for fast parallel search
-func searchWithFd(pattern, searchPath string) ([]SearchResult, error) {
-	args := []string{"--color", "never", "--hidden", "--no-ignore"}
-
-	// Type filter
-	if opts.Type == "f" {
-		args = append(args, "-t", "f")
-	} else if opts.Type == "d" {
-		args = append(args, "-t", "d")
-	}
-
-	// Case sensitivity
-	if opts.IgnoreCase {
-		args = append(args, "-i")
-	} else {
-		args = append(args, "-s")
-	}
+	"io"
 
-	// Age filter (fd uses --changed-before)
-	if opts.OlderThan > 0 {
-		args = append(args, "--changed-before", fmt.Sprintf("%ddays", opts.OlderThan))
-	}
-
-	// Size filter (fd uses -S for min size)
-	if opts.LargerThan != "" {
-		args = append(args, "-S", "+"+opts.LargerThan)
-	}
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
 
-	// Auto-exclude patterns
-	if !opts.All {
-		for _, pattern := range AutoExcludePatterns {
-			args = append(args, "-E", "*"+pattern+"*")
-		}
+// pathList holds the paths from --stdin or --from-file, which replace the search
+var pathList io.Reader
+
+// searchOptions maps the command-line flags onto the library search options
+func searchOptions(pattern, searchPath string) delf.SearchOptions {
+	return delf.SearchOptions{
+		Pattern:      pattern,
+		Root:         searchPath,
+		Roots:        searchRoots(),
+		Type:         opts.Type,
+		IgnoreCase:   opts.IgnoreCase,
+		All:          opts.All,
+		EmptyDirs:    opts.EmptyDirs,
+		MaxDepth:     opts.MaxDepth,
+		MinDepth:     opts.MinDepth,
+		Prune:        opts.Prune,
+		Limit:        opts.Limit,
+		Strict:       opts.Strict,
+		Paths:        pathList,
+		NulSeparated: opts.Null,
+		Filter:       filter,
+		Classifier:   classifier,
+		Sink:         sink,
 	}
+}
 
-	// Glob pattern and path
-	if pattern != "" {
-		args = append(args, "-g", pattern)
-	}
-	args = append(args, searchPath)
+// search performs the search using fd or fallback, reporting matches to the sink as they
+// arrive. When ctx is cancelled it returns what was found so far; the error is set when
+// fd failed, the root could not be read, or --strict met an unreadable entry.
+func search(ctx context.Context, pattern, searchPath string) ([]delf.Result, error) {
+	searcher := delf.NewSearcher(searchOptions(pattern, searchPath))
+	showSearchHeader(searcher, pattern)
+	fmt.Fprintf(console, "%s\n", colors.Bold("Matches:"))
 
-	cmd := exec.Command("fd", args...)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
+	// Matches are shown by the sink as they arrive
+	var results []delf.Result
+	for result := range searcher.Search(ctx) {
+		results = append(results, result)
 	}
 
-	if err := cmd.Start(); err != nil {
-		return nil, err
+	if searcher.LimitReached() {
+		fmt.Fprintf(console, "%s\n", colors.Yellow(fmt.Sprintf("  Stopped at --limit %d results; more may exist", opts.Limit)))
 	}
-
-	var results []SearchResult
-	scanner := bufio.NewScanner(stdout)
-	count := 0
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
-
-		count++
-		category := categorizeFile(line)
-
-		// Check if it's a directory
-		info, err := os.Stat(line)
-		isDir := err == nil && info.IsDir()
-
-		results = append(results, SearchResult{
-			Path:     line,
-			Category: category,
-			IsDir:    isDir,
-		})
-
-		// Display result in real-time (streaming)
-		if count <= opts.MaxDisplay {
-			showResult(line, count, category)
-		} else if count == opts.MaxDisplay+1 {
-			fmt.Printf("%s\n", colors.Yellow("  ... (more results, display limit reached)"))
-		}
+	if !opts.Strict {
+		showUnreadable(searcher.Errors())
 	}
 
-	cmd.Wait()
-	return results, nil
+	return results, searcher.Err()
 }
 
-// searchWithWalk uses filepath.WalkDir as fallback
-func searchWithWalk(pattern, searchPath string) ([]SearchResult, error) {
-	var results []SearchResult
-	count := 0
-
-	err := filepath.WalkDir(searchPath, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil // Skip errors, continue walking
-		}
-
-		// Skip the root directory itself
-		if path == searchPath {
-			return nil
-		}
-
-		// Type filter
-		if opts.Type == "f" && d.IsDir() {
-			return nil
+// showSearchHeader describes where matches come from
+func showSearchHeader(searcher *delf.Searcher, pattern string) {
+	switch {
+	case pathList != nil:
+		source := "stdin"
+		if opts.FromFile != "" {
+			source = opts.FromFile
 		}
-		if opts.Type == "d" && !d.IsDir() {
-			return nil
-		}
-
-		// Auto-exclude check
-		if !opts.All && isAutoExcluded(path) {
-			if d.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Pattern matching (if pattern provided)
-		if pattern != "" {
-			name := d.Name()
-			matched := matchPattern(name, pattern, opts.IgnoreCase)
-			if !matched {
-				return nil
-			}
-		}
-
-		// Get file info for filtering
-		info, err := d.Info()
-		if err != nil {
-			return nil
-		}
-
-		// Age filter
-		if opts.OlderThan > 0 {
-			cutoff := time.Now().AddDate(0, 0, -opts.OlderThan)
-			if info.ModTime().After(cutoff) {
-				return nil
-			}
-		}
-
-		// Size filter (only for files)
-		if opts.LargerThan != "" && !d.IsDir() {
-			minSize, err := parseSize(opts.LargerThan)
-			if err == nil && info.Size() <= minSize {
-				return nil
-			}
-		}
-
-		count++
-		category := categorizeFile(path)
-
-		results = append(results, SearchResult{
-			Path:     path,
-			Category: category,
-			IsDir:    d.IsDir(),
-		})
-
-		// Display result in real-time (streaming)
-		if count <= opts.MaxDisplay {
-			showResult(path, count, category)
-		} else if count == opts.MaxDisplay+1 {
-			fmt.Printf("%s\n", colors.Yellow("  ... (more results, display limit reached)"))
-		}
-
-		return nil
-	})
-
-	return results, err
-}
-
-// searchEmptyDirs finds empty directories
-func searchEmptyDirs(searchPath string) ([]SearchResult, error) {
-	var results []SearchResult
-	count := 0
-
-	err := filepath.WalkDir(searchPath, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil
-		}
-
-		if path == searchPath {
-			return nil
-		}
-
-		if !d.IsDir() {
-			return nil
-		}
-
-		// Auto-exclude check
-		if !opts.All && isAutoExcluded(path) {
-			return filepath.SkipDir
-		}
-
-		isEmpty, err := isEmptyDirectory(path)
-		if err != nil || !isEmpty {
-			return nil
-		}
-
-		count++
-		category := categorizeFile(path)
-
-		results = append(results, SearchResult{
-			Path:     path,
-			Category: category,
-			IsDir:    true,
-		})
-
-		if count <= opts.MaxDisplay {
-			showResult(path, count, category)
-		} else if count == opts.MaxDisplay+1 {
-			fmt.Printf("%s\n", colors.Yellow("  ... (more results, display limit reached)"))
-		}
-
-		return nil
-	})
-
-	return results, err
-}
-
-// matchPattern checks if name matches the glob pattern
-func matchPattern(name, pattern string, ignoreCase bool) bool {
-	if ignoreCase {
-		name = strings.ToLower(name)
-		pattern = strings.ToLower(pattern)
+		showListInfo(source, pattern)
+	case opts.EmptyDirs:
+		showSearchInfo(searcher.Roots(), "(empty directories)", false)
+	default:
+		showSearchInfo(searcher.Roots(), pattern, searcher.UsingFd())
 	}
-
-	matched, err := filepath.Match(pattern, name)
-	if err != nil {
-		return false
-	}
-	return matched
 }
 
-// search performs the search using fd or fallback
-func search(pattern, searchPath string) ([]SearchResult, bool) {
-	// Resolve search path
-	absPath, err := filepath.Abs(searchPath)
-	if err != nil {
-		absPath = searchPath
+// showUnreadable summarizes the entries a search could not read or check
+func showUnreadable(errs []*delf.EntryError) {
+	if len(errs) == 0 {
+		return
 	}
 
-	// Handle empty dirs mode
-	if opts.EmptyDirs {
-		showSearchInfo(absPath, "(empty directories)", false)
-		fmt.Printf("%s\n", colors.Bold("Matches:"))
-		results, _ := searchEmptyDirs(absPath)
-		return results, false
+	dirs := 0
+	for _, e := range errs {
+		if e.Dir {
+			dirs++
+		}
 	}
-
-	usingFd := hasFd()
-	showSearchInfo(absPath, pattern, usingFd)
-
-	fmt.Printf("%s\n", colors.Bold("Matches:"))
-
-	var results []SearchResult
-	if usingFd {
-		results, _ = searchWithFd(pattern, absPath)
-	} else {
-		results, _ = searchWithWalk(pattern, absPath)
+	fmt.Fprintln(console)
+	if dirs > 0 {
+		fmt.Fprintf(console, "%s %d unreadable directories\n", colors.Yellow("WARNING: Skipped"), dirs)
 	}
-
-	return results, usingFd
-}
-
-// getTotalSize calculates total size of all files
-func getTotalSize(results []SearchResult) int64 {
-	var total int64
-	for _, result := range results {
-		info, err := os.Stat(result.Path)
-		if err != nil {
-			continue
-		}
-		if info.IsDir() {
-			// Sum up directory contents
-			filepath.WalkDir(result.Path, func(path string, d os.DirEntry, err error) error {
-				if err != nil || d.IsDir() {
-					return nil
-				}
-				info, err := d.Info()
-				if err == nil {
-					total += info.Size()
-				}
-				return nil
-			})
-		} else {
-			total += info.Size()
-		}
+	if entries := len(errs) - dirs; entries > 0 {
+		fmt.Fprintf(console, "%s %d entries that could not be read or checked\n", colors.Yellow("WARNING: Skipped"), entries)
 	}
-	return total
+	fmt.Fprintf(console, "%s\n", colors.Dim(fmt.Sprintf("  first: %v (use --strict to stop instead)", errs[0])))
 }
 
 // countByCategory counts results by category
-func countByCategory(results []SearchResult) (critical, warning, safe int) {
+func countByCategory(results []delf.Result) (critical, warning, safe int) {
 	for _, r := range results {
-		switch r.Category {
-		case CategoryCritical:
+		switch {
+		case r.Category == delf.CategoryCritical:
 			critical++
-		case CategoryWarning:
+		case r.Category == delf.CategoryWarning || r.Git.IsProtected():
 			warning++
 		default:
 			safe++
@@ -330,10 +112,10 @@ func countByCategory(results []SearchResult) (critical, warning, safe int) {
 }
 
 // filterOutCritical removes critical files from results (for non-admin)
-func filterOutCritical(results []SearchResult) []SearchResult {
-	var filtered []SearchResult
+func filterOutCritical(results []delf.Result) []delf.Result {
+	var filtered []delf.Result
 	for _, r := range results {
-		if r.Category != CategoryCritical {
+		if r.Category != delf.CategoryCritical {
 			filtered = append(filtered, r)
 		}
 	}
@@ -341,9 +123,9 @@ func filterOutCritical(results []SearchResult) []SearchResult {
 }
 
 // filterByExclusions removes files matching exclusion patterns
-func filterByExclusions(results []SearchResult, patterns []string) (kept, excluded []SearchResult) {
+func filterByExclusions(results []delf.Result, patterns []string) (kept, excluded []delf.Result) {
 	for _, r := range results {
-		if matchesExclusionPattern(r.Path, patterns) {
+		if delf.MatchesExclusionPattern(r.Path, patterns) {
 			excluded = append(excluded, r)
 		} else {
 			kept = append(kept, r)
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// shredFlag implements --shred[=passes] as an optional-value flag
+type shredFlag struct {
+	passes *int
+}
+
+func (f shredFlag) String() string {
+	if f.passes == nil {
+		return "0"
+	}
+	return strconv.Itoa(*f.passes)
+}
+
+func (f shredFlag) Set(value string) error {
+	switch value {
+	case "true":
+		*f.passes = delf.DefaultShredPasses
+		return nil
+	case "false":
+		*f.passes = 0
+		return nil
+	}
+
+	passes, err := strconv.Atoi(value)
+	if err != nil || passes < 1 {
+		return fmt.Errorf("passes must be a positive number")
+	}
+	*f.passes = passes
+	return nil
+}
+
+func (f shredFlag) IsBoolFlag() bool {
+	return true
+}
+
+// shredPassesFlag implements --shred-passes N, which takes its value as the
+// next argument like any other number flag
+type shredPassesFlag struct {
+	passes *int
+}
+
+func (f shredPassesFlag) String() string {
+	return shredFlag(f).String()
+}
+
+func (f shredPassesFlag) Set(value string) error {
+	passes, err := strconv.Atoi(value)
+	if err != nil || passes < 1 {
+		return fmt.Errorf("passes must be a positive number")
+	}
+	*f.passes = passes
+	return nil
+}
+
+// shredCountAsPattern reports whether args hold "--shred N", where the flag
+// package took N for the pattern because --shred only reads "=PASSES"
+func shredCountAsPattern(args []string, pattern string) bool {
+	if _, err := strconv.Atoi(pattern); err != nil {
+		return false
+	}
+	for i := 0; i+1 < len(args) && args[i] != "--"; i++ {
+		if (args[i] == "--shred" || args[i] == "-shred") && args[i+1] == pattern {
+			return true
+		}
+	}
+	return false
+}
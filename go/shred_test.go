@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+func TestShredFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		passes  int
+		pattern string
+		wantErr bool
+	}{
+		{[]string{"--shred", "*.key"}, delf.DefaultShredPasses, "*.key", false},
+		{[]string{"--shred=7", "*.key"}, 7, "*.key", false},
+		{[]string{"--shred-passes", "5", "*.key"}, 5, "*.key", false},
+		{[]string{"--shred-passes=2", "*.key"}, 2, "*.key", false},
+		{[]string{"--shred-passes", "0", "*.key"}, 0, "", true},
+		{[]string{"--shred=x", "*.key"}, 0, "", true},
+	}
+	for _, tt := range tests {
+		var passes int
+		fs := flag.NewFlagSet("delf", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Var(shredFlag{&passes}, "shred", "")
+		fs.Var(shredPassesFlag{&passes}, "shred-passes", "")
+		err := fs.Parse(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: error %v, want error %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (passes != tt.passes || fs.Arg(0) != tt.pattern) {
+			t.Errorf("%q: %d passes, pattern %q; want %d, %q", tt.args, passes, fs.Arg(0), tt.passes, tt.pattern)
+		}
+	}
+}
+
+func TestShredCountAsPattern(t *testing.T) {
+	tests := []struct {
+		args    []string
+		pattern string
+		want    bool
+	}{
+		{[]string{"--shred", "5", "secrets"}, "5", true},
+		{[]string{"-shred", "5"}, "5", true},
+		{[]string{"-n", "--shred", "12", "."}, "12", true},
+		{[]string{"--shred", "*.key"}, "*.key", false},
+		{[]string{"--shred=5", "5"}, "5", false},
+		{[]string{"--shred", "--", "5"}, "5", false},
+		{[]string{"--shred", "-p", "5"}, "5", false},
+	}
+	for _, tt := range tests {
+		if got := shredCountAsPattern(tt.args, tt.pattern); got != tt.want {
+			t.Errorf("shredCountAsPattern(%q, %q) = %v, want %v", tt.args, tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+// useStreaming reports whether matches are deleted as they are found instead of
+// being collected, previewed and confirmed as a batch. Forced and JSON runs stream
+// unless an option needs the whole result set first.
+func useStreaming() bool {
+	if !opts.Force && !opts.JSON {
+		return false
+	}
+	return opts.Top == 0 && !opts.GitSafe && opts.Archive == "" && !retention.Active()
+}
+
+// streamDeletion searches and disposes of each match as it arrives, so memory
+// stays bounded however many entries match. Matched directories are deleted
+// whole and not searched, as nested matches are folded into them in batch runs.
+func streamDeletion() {
+	deleter, err := newDeleter()
+	if err != nil {
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+
+	searchOpts := searchOptions(opts.Pattern, opts.Path)
+	searchOpts.Prune = true
+	searcher := delf.NewSearcher(searchOpts)
+
+	showSearchHeader(searcher, opts.Pattern)
+
+	// Shredding is best-effort on some storage; it is checked at the search root
+	if opts.Shred > 0 {
+		showShredWarnings(delf.ShredWarnings([]delf.Result{{Path: filepath.Join(searcher.Root(), "*")}}), opts.Shred)
+	}
+
+	// Without --force there is one confirmation up front, since nothing can be previewed
+	if !opts.Force && !opts.DryRun {
+		fmt.Fprintf(console, "%s matches are %s as they are found, without a preview\n",
+			colors.Yellow("Note:"), colors.Red(strings.ToLower(deleter.Verb())))
+		if !confirmDeletion() {
+			fmt.Fprintln(console)
+			fmt.Fprintln(console, colors.Yellow("Operation cancelled"))
+			os.Exit(2)
+		}
+	}
+
+	// A move manifest is created up front, so nothing is moved unrecorded.
+	// Archives need every match first and never stream.
+	if p, ok := deleter.(delf.Preparer); ok {
+		if _, err := p.Prepare(nil); err != nil {
+			fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+			os.Exit(1)
+		}
+	}
+
+	// Record the run in the audit log
+	var audit *auditLog
+	if !opts.NoAudit && !opts.DryRun {
+		audit, err = openAuditLog(auditPath(), opts.AuditHash, opts.SysLog)
+		if err != nil {
+			fmt.Fprintf(console, "%s audit log unavailable: %v\n", colors.Yellow("Warning:"), err)
+		}
+	}
+
+	// Open files are looked up once, not per match
+	open, _ := delf.SnapshotOpenFiles()
+
+	if t, ok := sink.(*terminalSink); ok {
+		t.streaming = true
+	}
+	fmt.Fprintln(console, colors.Bold(colors.Red(deleter.Heading())))
+	fmt.Fprintln(console)
+
+	ctx, stop := withSignals()
+	admin := isAdmin()
+	var deleted, failed, skipped, protected, matched int
+	var size int64
+
+	for result := range searcher.Search(ctx) {
+		// Once interrupted, only drain what the search already sent
+		if ctx.Err() != nil {
+			continue
+		}
+		matched++
+
+		// Critical system paths need an administrator and an explicit --force
+		if result.Category == delf.CategoryCritical && (!admin || !opts.Force) {
+			protected++
+			sink.Event(delf.Event{Kind: delf.EventSkipped, Path: result.Path, Result: result, Reason: "critical system path"})
+			continue
+		}
+
+		result.Holders = open.Holders(result.Path, result.IsDir)
+
+		var itemSize int64
+		if opts.ShowSize {
+			itemSize = delf.PathSize(result.Path)
+		}
+
+		switch disposeOf(result, deleter, audit) {
+		case itemDeleted:
+			deleted++
+			size += itemSize
+		case itemFailed:
+			failed++
+		case itemSkipped:
+			skipped++
+		}
+	}
+
+	interrupted := ctx.Err() != nil
+	stop()
+	finishDeleter(deleter)
+	audit.close(deleted, failed, skipped, 0, interrupted)
+
+	if matched == 0 && !interrupted && searcher.Err() == nil {
+		showNoMatches()
+		os.Exit(1)
+	}
+
+	if searcher.LimitReached() {
+		fmt.Fprintln(console)
+		fmt.Fprintf(console, "%s\n", colors.Yellow(fmt.Sprintf("Stopped at --limit %d results; more may exist", opts.Limit)))
+	}
+	if !opts.Strict {
+		showUnreadable(searcher.Errors())
+	}
+
+	// Show final summary
+	showDeletionProgress(deleter.Verb(), deleted, failed, skipped)
+	if protected > 0 {
+		fmt.Fprintf(console, "%s %d critical system paths left in place\n", colors.Yellow("! Protected:"), protected)
+	}
+	if opts.ShowSize {
+		fmt.Fprintf(console, "%s %s\n", colors.Bold("Total size:"), colors.Yellow(formatSize(size)))
+	}
+
+	if interrupted {
+		fmt.Fprintln(console)
+		fmt.Fprintf(console, "%s the search was stopped; matches it had not reached were not touched\n", colors.Yellow(colors.Bold("Interrupted:")))
+		os.Exit(exitInterrupted)
+	}
+	if err := searcher.Err(); err != nil {
+		fmt.Fprintln(console)
+		fmt.Fprintf(console, "%s %v\n", colors.Red("ERROR:"), err)
+		os.Exit(1)
+	}
+	if opts.DryRun {
+		showDryRunNotice()
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ReggieAlbiosA/delf/pkg/delf"
+)
+
+func TestUseStreaming(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func()
+		want  bool
+	}{
+		{"interactive", func() {}, false},
+		{"force", func() { opts.Force = true }, true},
+		{"json", func() { opts.JSON = true }, true},
+		{"force with --top", func() { opts.Force, opts.Top = true, 10 }, false},
+		{"force with --git-safe", func() { opts.Force, opts.GitSafe = true, true }, false},
+		{"json with --archive", func() { opts.JSON, opts.Archive = true, "out.tar" }, false},
+		{"force with retention", func() { opts.Force, retention = true, delf.Retention{KeepNewest: 3} }, false},
+		{"force with --move-to", func() { opts.Force, opts.MoveTo = true, "quarantine" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recordRun(t)
+			saved := retention
+			defer func() { retention = saved }()
+			opts = Options{}
+			retention = delf.Retention{}
+
+			tt.setup()
+			if got := useStreaming(); got != tt.want {
+				t.Fatalf("useStreaming() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// streamTree lays out matches for a streaming run: two files, a matched
+// directory with a match inside it, and a file that does not match
+func streamTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, path := range []string{"a.log", "sub/b.log", "old.log/c.log", "keep.txt"} {
+		touchFile(t, filepath.Join(root, path))
+	}
+	return root
+}
+
+// runStream runs streamDeletion over root after setup has set the flags under
+// test, and returns its JSON events as "event path" lines
+func runStream(t *testing.T, root string, setup func()) []string {
+	t.Helper()
+	recordRun(t)
+	var events bytes.Buffer
+	sink = delf.NewJSONSink(&events)
+	opts.Pattern, opts.Path, opts.NoAudit = "*.log", root, true
+	setup()
+	if !useStreaming() {
+		t.Fatal("run would not stream")
+	}
+
+	streamDeletion()
+
+	var got []string
+	scanner := bufio.NewScanner(&events)
+	for scanner.Scan() {
+		var e struct {
+			Event string `json:"event"`
+			Path  string `json:"path"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("bad JSON line %q: %v", scanner.Text(), err)
+		}
+		rel, _ := filepath.Rel(root, e.Path)
+		got = append(got, e.Event+" "+filepath.ToSlash(rel))
+	}
+	return got
+}
+
+// checkStreamEvents checks that every match is reported, then deleted, once,
+// and that nothing inside the matched directory is reported
+func checkStreamEvents(t *testing.T, root string, events []string) {
+	t.Helper()
+	order := make(map[string]int)
+	for i, e := range events {
+		if _, dup := order[e]; dup {
+			t.Fatalf("event %q sent twice: %q", e, events)
+		}
+		order[e] = i
+	}
+	for _, path := range []string{"a.log", "sub/b.log", "old.log"} {
+		found, ok1 := order["result "+path]
+		deleted, ok2 := order["deleted "+path]
+		if !ok1 || !ok2 || found > deleted {
+			t.Fatalf("%s is not reported then deleted: %q", path, events)
+		}
+		if _, err := os.Lstat(filepath.Join(root, path)); !os.IsNotExist(err) {
+			t.Fatalf("%s was not deleted", path)
+		}
+	}
+	if len(events) != 6 {
+		t.Fatalf("events = %q, want a result and a deletion for each of 3 matches", events)
+	}
+	if _, err := os.Stat(filepath.Join(root, "keep.txt")); err != nil {
+		t.Fatal("non-matching file was deleted")
+	}
+}
+
+func TestStreamDeletionForce(t *testing.T) {
+	root := streamTree(t)
+	checkStreamEvents(t, root, runStream(t, root, func() { opts.Force = true }))
+}
+
+func TestStreamDeletionJSON(t *testing.T) {
+	root := streamTree(t)
+
+	// Without --force a streaming run asks once up front
+	saved := reader
+	defer func() { reader = saved }()
+	reader = bufio.NewReader(strings.NewReader("y\n"))
+
+	checkStreamEvents(t, root, runStream(t, root, func() { opts.JSON = true }))
+}
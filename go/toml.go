@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tomlTable is one table of a rules file: the top level or a [[rule]] entry
+type tomlTable struct {
+	line   int                    // line of the table header, 0 for the top level
+	keys   []string               // keys in file order
+	values map[string]interface{} // string, int64, bool or []interface{}
+	lines  map[string]int         // line each key was set on
+}
+
+func newTOMLTable(line int) *tomlTable {
+	return &tomlTable{line: line, values: make(map[string]interface{}), lines: make(map[string]int)}
+}
+
+// parseTOML reads the subset of TOML that rules files use: comments, key = value
+// pairs, [[rule]] headers, basic and literal strings, integers, booleans and
+// arrays. Anything else is reported with its line number.
+func parseTOML(src string) (top *tomlTable, rules []*tomlTable, err error) {
+	p := &tomlParser{src: strings.ReplaceAll(src, "\r\n", "\n"), line: 1}
+	top = newTOMLTable(0)
+	table := top
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return top, rules, nil
+		}
+
+		// Table headers
+		if p.peek() == '[' {
+			line := p.line
+			if !strings.HasPrefix(p.rest(), "[[") {
+				return nil, nil, p.errorf("tables other than [[rule]] are not supported")
+			}
+			p.pos += 2
+			p.skipSpace()
+			name, err := p.key()
+			if err != nil {
+				return nil, nil, err
+			}
+			p.skipSpace()
+			if !strings.HasPrefix(p.rest(), "]]") {
+				return nil, nil, p.errorf("expected ]] after [[%s", name)
+			}
+			p.pos += 2
+			if name != "rule" {
+				return nil, nil, fmt.Errorf("line %d: unknown table [[%s]] (only [[rule]] is supported)", line, name)
+			}
+			if err := p.endOfLine(); err != nil {
+				return nil, nil, err
+			}
+			table = newTOMLTable(line)
+			rules = append(rules, table)
+			continue
+		}
+
+		// key = value
+		line := p.line
+		key, err := p.key()
+		if err != nil {
+			return nil, nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.peek() != '=' {
+			return nil, nil, p.errorf("expected = after %s", key)
+		}
+		p.pos++
+		p.skipSpace()
+		value, err := p.value()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, nil, err
+		}
+		if _, dup := table.values[key]; dup {
+			return nil, nil, fmt.Errorf("line %d: %s is set twice", line, key)
+		}
+		table.keys = append(table.keys, key)
+		table.values[key] = value
+		table.lines[key] = line
+	}
+}
+
+// tomlParser walks the source text, counting lines for error messages
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) rest() string {
+	return p.src[p.pos:]
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips spaces and tabs
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine accepts trailing space and a comment before the newline
+func (p *tomlParser) endOfLine() error {
+	p.skipSpace()
+	if !p.eof() && p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+	if !p.eof() && p.peek() != '\n' {
+		return p.errorf("unexpected %q after value", p.rest()[:1])
+	}
+	return nil
+}
+
+// key reads a bare or quoted key
+func (p *tomlParser) key() (string, error) {
+	if !p.eof() && (p.peek() == '"' || p.peek() == '\'') {
+		return p.string()
+	}
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if p.pos == start {
+		if p.eof() {
+			return "", p.errorf("expected a key")
+		}
+		return "", p.errorf("expected a key, found %q", p.rest()[:1])
+	}
+	if !p.eof() && p.peek() == '.' {
+		return "", p.errorf("dotted keys are not supported")
+	}
+	return p.src[start:p.pos], nil
+}
+
+// value reads a string, integer, boolean or array
+func (p *tomlParser) value() (interface{}, error) {
+	if p.eof() || p.peek() == '\n' {
+		return nil, p.errorf("missing value")
+	}
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.string()
+	case c == '[':
+		return p.array()
+	case strings.HasPrefix(p.rest(), "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(p.rest(), "false"):
+		p.pos += 5
+		return false, nil
+	case c == '-' || c == '+' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		for !p.eof() && (p.peek() >= '0' && p.peek() <= '9' || p.peek() == '_') {
+			p.pos++
+		}
+		n, err := strconv.ParseInt(strings.ReplaceAll(p.src[start:p.pos], "_", ""), 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer %s", p.src[start:p.pos])
+		}
+		return n, nil
+	}
+	return nil, p.errorf("unsupported value (use a quoted string, integer, true/false or array)")
+}
+
+// string reads a "basic" string with escapes or a 'literal' one
+func (p *tomlParser) string() (string, error) {
+	quote := p.peek()
+	if strings.HasPrefix(p.rest(), strings.Repeat(string(quote), 3)) {
+		return "", p.errorf("multi-line strings are not supported")
+	}
+	p.pos++
+
+	var b strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\n':
+			return "", p.errorf("unterminated string")
+		case c == '\\' && quote == '"':
+			if p.eof() {
+				return "", p.errorf("unterminated string")
+			}
+			e := p.peek()
+			p.pos++
+			switch e {
+			case '"', '\\':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u', 'U':
+				r, err := p.unicodeEscape(e)
+				if err != nil {
+					return "", err
+				}
+				b.WriteRune(r)
+			default:
+				return "", p.errorf("unsupported escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// unicodeEscape reads the hex digits of a \uXXXX or \UXXXXXXXX escape
+func (p *tomlParser) unicodeEscape(e byte) (rune, error) {
+	digits := 4
+	if e == 'U' {
+		digits = 8
+	}
+	if len(p.rest()) < digits {
+		return 0, p.errorf("\\%c needs %d hex digits", e, digits)
+	}
+	hex := p.src[p.pos : p.pos+digits]
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, p.errorf("\\%c needs %d hex digits", e, digits)
+	}
+	r := rune(n)
+	if !utf8.ValidRune(r) {
+		return 0, p.errorf("\\%c%s is not a valid Unicode scalar value", e, hex)
+	}
+	p.pos += digits
+	return r, nil
+}
+
+// array reads [v, v, ...], which may span lines and hold comments
+func (p *tomlParser) array() ([]interface{}, error) {
+	p.pos++
+	var items []interface{}
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return items, nil
+		}
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected , or ] in array")
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOMLValues(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		{"basic string", `v = "*.log"`, "*.log"},
+		{"literal string", `v = 'C:\logs\*.tmp'`, `C:\logs\*.tmp`},
+		{"escaped quote and backslash", `v = "a\"b\\c"`, `a"b\c`},
+		{"control escapes", `v = "\b\f\n\r\t"`, "\b\f\n\r\t"},
+		{"short unicode escape", `v = "caf\u00e9"`, "café"},
+		{"long unicode escape", `v = "\U0001F600"`, "\U0001F600"},
+		{"empty string", `v = ""`, ""},
+		{"hash inside string", `v = "#1" # comment`, "#1"},
+		{"integer", `v = 42`, int64(42)},
+		{"negative integer", `v = -7`, int64(-7)},
+		{"integer with underscores", `v = 1_000`, int64(1000)},
+		{"true", `v = true`, true},
+		{"false", `v = false`, false},
+		{"array", `v = ["a", 'b', 3]`, []interface{}{"a", "b", int64(3)}},
+		{"empty array", `v = []`, []interface{}(nil)},
+		{"multi-line array", "v = [\n  \"a\", # first\n  \"b\",\n]", []interface{}{"a", "b"}},
+		{"quoted key", `"v" = 1`, int64(1)},
+		{"crlf line endings", "# c\r\nv = 1\r\n", int64(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			top, _, err := parseTOML(tt.src)
+			if err != nil {
+				t.Fatalf("parseTOML(%q): %v", tt.src, err)
+			}
+			if got := top.values["v"]; !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseTOML(%q) = %#v, want %#v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLRules(t *testing.T) {
+	src := `# defaults
+dry_run = true
+
+[[rule]]
+name = "logs"
+path = "/var/log/app"
+
+[[ rule ]]  # spaces and a comment
+name = "tmp"
+enabled = false
+`
+	top, rules, err := parseTOML(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(top.keys, []string{"dry_run"}) {
+		t.Fatalf("top keys = %v", top.keys)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(rules))
+	}
+	if rules[0].line != 4 || rules[1].line != 8 {
+		t.Fatalf("rule lines = %d, %d, want 4, 8", rules[0].line, rules[1].line)
+	}
+	if !reflect.DeepEqual(rules[0].keys, []string{"name", "path"}) || rules[0].lines["path"] != 6 {
+		t.Fatalf("rule 1 keys = %v, lines = %v", rules[0].keys, rules[0].lines)
+	}
+	if rules[1].values["enabled"] != false {
+		t.Fatalf("rule 2 enabled = %v", rules[1].values["enabled"])
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"unknown escape", `v = "C:\logs"`, `line 1: unsupported escape \l`},
+		{"short unicode escape", `v = "\u00e"`, `line 1: \u needs 4 hex digits`},
+		{"truncated unicode escape", `v = "\u12`, `line 1: \u needs 4 hex digits`},
+		{"surrogate escape", `v = "\ud800"`, `line 1: \ud800 is not a valid Unicode scalar value`},
+		{"escape out of range", `v = "\U00110000"`, `line 1: \U00110000 is not a valid Unicode scalar value`},
+		{"unterminated string", `v = "abc`, "line 1: unterminated string"},
+		{"string across lines", "v = \"abc\n\"", "line 1: unterminated string"},
+		{"multi-line string", `v = """abc"""`, "line 1: multi-line strings are not supported"},
+		{"missing value", "v =\n", "line 1: missing value"},
+		{"missing equals", "v 1", "line 1: expected = after v"},
+		{"float", "v = 1.5", `line 1: unexpected "." after value`},
+		{"bare word", "v = yes", "line 1: unsupported value"},
+		{"trailing text", `v = "a" "b"`, `line 1: unexpected "\"" after value`},
+		{"duplicate key", "v = 1\nv = 2", "line 2: v is set twice"},
+		{"dotted key", "a.b = 1", "line 1: dotted keys are not supported"},
+		{"plain table", "\n[rule]", "line 2: tables other than [[rule]] are not supported"},
+		{"unknown table", "[[job]]", "line 1: unknown table [[job]]"},
+		{"unclosed header", "[[rule", "line 1: expected ]] after [[rule"},
+		{"unterminated array", "v = [1,\n2", "line 2: unterminated array"},
+		{"array without comma", "v = [1 2]", "line 1: expected , or ] in array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseTOML(tt.src)
+			if err == nil {
+				t.Fatalf("parseTOML(%q) succeeded", tt.src)
+			}
+			if !strings.HasPrefix(err.Error(), tt.want) {
+				t.Fatalf("parseTOML(%q) error %q, want %q", tt.src, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !windows
+
+package main
+
+// ttyPath is the controlling terminal, used for prompts when stdin carries a path list
+const ttyPath = "/dev/tty"
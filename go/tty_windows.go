@@ -0,0 +1,4 @@
+package main
+
+// ttyPath is the console input, used for prompts when stdin carries a path list
+const ttyPath = "CONIN$"